@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"github.com/ydb-platform/ydb-go-sdk/v3/metrics"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+)
+
+// balancer makes driver trace with metrics of balancer churn and routing of requests to local or remote DC
+func balancer(config metrics.Config) (t trace.Driver) {
+	config = config.WithSystem("driver").WithSystem("balancer")
+	added := config.CounterVec("endpoints_added", "az")
+	dropped := config.CounterVec("endpoints_dropped", "az")
+	chosen := config.CounterVec("chosen_endpoints", "dc")
+
+	t.OnBalancerUpdate = func(info trace.DriverBalancerUpdateStartInfo) func(trace.DriverBalancerUpdateDoneInfo) {
+		return func(info trace.DriverBalancerUpdateDoneInfo) {
+			if config.Details()&trace.DriverBalancerEvents != 0 {
+				for _, e := range info.Added {
+					added.With(map[string]string{
+						"az": e.Location(),
+					}).Inc()
+				}
+				for _, e := range info.Dropped {
+					dropped.With(map[string]string{
+						"az": e.Location(),
+					}).Inc()
+				}
+			}
+		}
+	}
+	t.OnBalancerChooseEndpoint = func(
+		info trace.DriverBalancerChooseEndpointStartInfo,
+	) func(trace.DriverBalancerChooseEndpointDoneInfo) {
+		return func(info trace.DriverBalancerChooseEndpointDoneInfo) {
+			if config.Details()&trace.DriverBalancerEvents != 0 && info.Error == nil && info.Endpoint != nil {
+				dc := "remote"
+				if info.Endpoint.LocalDC() {
+					dc = "local"
+				}
+				chosen.With(map[string]string{
+					"dc": dc,
+				}).Inc()
+			}
+		}
+	}
+
+	return t
+}
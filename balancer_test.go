@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+)
+
+func TestBalancer(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	d := balancer(Config(registry).WithSystem("ydb"))
+	local := testEndpoint{address: "node-1:2135", nodeID: 1, location: "a", localDC: true}
+	remote := testEndpoint{address: "node-2:2135", nodeID: 2, location: "b"}
+
+	choose := func(e trace.EndpointInfo) {
+		d.OnBalancerChooseEndpoint(trace.DriverBalancerChooseEndpointStartInfo{})(
+			trace.DriverBalancerChooseEndpointDoneInfo{Endpoint: e},
+		)
+	}
+	d.OnBalancerUpdate(trace.DriverBalancerUpdateStartInfo{})(trace.DriverBalancerUpdateDoneInfo{
+		Endpoints: []trace.EndpointInfo{local, remote},
+		Added:     []trace.EndpointInfo{local, remote},
+		LocalDC:   "a",
+	})
+	choose(local)
+	choose(local)
+	choose(remote)
+	d.OnBalancerUpdate(trace.DriverBalancerUpdateStartInfo{})(trace.DriverBalancerUpdateDoneInfo{
+		Endpoints: []trace.EndpointInfo{local},
+		Dropped:   []trace.EndpointInfo{remote},
+		LocalDC:   "a",
+	})
+
+	assertValue(t, registry, "ydb_go_sdk_ydb_driver_balancer_endpoints_added", nil, 2)
+	assertValue(t, registry, "ydb_go_sdk_ydb_driver_balancer_endpoints_dropped", map[string]string{"az": "b"}, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_driver_balancer_chosen_endpoints", map[string]string{"dc": "local"}, 2)
+	assertValue(t, registry, "ydb_go_sdk_ydb_driver_balancer_chosen_endpoints", map[string]string{"dc": "remote"}, 1)
+}
+
+func TestDiscovery(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	d := discovery(Config(registry).WithSystem("ydb"))
+	d.OnDiscover(trace.DiscoveryDiscoverStartInfo{})(trace.DiscoveryDiscoverDoneInfo{
+		Endpoints: []trace.EndpointInfo{testEndpoint{}, testEndpoint{}},
+	})
+
+	assertValue(t, registry, "ydb_go_sdk_ydb_discovery_latency", map[string]string{"status": "OK"}, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_discovery_endpoints", nil, 1)
+}
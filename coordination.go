@@ -0,0 +1,347 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-genproto/Ydb_Coordination_V1"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb_Coordination"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	ydbConfig "github.com/ydb-platform/ydb-go-sdk/v3/config"
+	"github.com/ydb-platform/ydb-go-sdk/v3/metrics"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+	"google.golang.org/grpc"
+)
+
+// coordinationRequest is a semaphore request which waits for result from server
+type coordinationRequest struct {
+	name    string
+	acquire bool
+	start   time.Time
+}
+
+// coordinationSession is a tracked state of coordination session. Session lives across streams:
+// on reconnect ydb-go-sdk starts same session on new stream and resends pending requests there,
+// so held semaphores and pending requests are kept until session is stopped or expired
+type coordinationSession struct {
+	path     string
+	held     map[string]struct{}
+	requests map[uint64]coordinationRequest
+}
+
+type coordinationMetrics struct {
+	states         metrics.CounterVec
+	acquireLatency metrics.TimerVec
+	acquires       metrics.CounterVec
+	releases       metrics.CounterVec
+	held           metrics.GaugeVec
+
+	m        sync.Mutex
+	sessions map[uint64]*coordinationSession
+}
+
+func newCoordinationMetrics(config metrics.Config) *coordinationMetrics {
+	config = config.WithSystem("coordination")
+	semaphore := config.WithSystem("semaphore")
+
+	return &coordinationMetrics{
+		states:         config.CounterVec("session_states", "state"),
+		acquireLatency: semaphore.TimerVec("acquire_latency", "path"),
+		acquires:       semaphore.CounterVec("acquires", "path", "outcome"),
+		releases:       semaphore.CounterVec("releases", "path", "outcome"),
+		held:           semaphore.GaugeVec("held", "path"),
+		sessions:       make(map[uint64]*coordinationSession),
+	}
+}
+
+// coordination returns driver options with metrics of coordination sessions and semaphores
+func coordination(config metrics.Config) ydb.Option {
+	m := newCoordinationMetrics(config)
+
+	return ydb.MergeOptions(
+		ydb.WithTraceCoordination(m.trace(config)),
+		ydb.With(ydbConfig.WithGrpcOptions(grpc.WithChainStreamInterceptor(m.streamInterceptor(config)))),
+	)
+}
+
+func (m *coordinationMetrics) trace(config metrics.Config) (t trace.Coordination) {
+	onState := func(state string) {
+		if config.Details()&trace.CoordinationEvents != 0 {
+			m.states.With(map[string]string{
+				"state": state,
+			}).Inc()
+		}
+	}
+	t.OnSessionStarted = func(info trace.CoordinationSessionStartedInfo) {
+		onState("connected")
+	}
+	t.OnSessionKeepAliveTimeout = func(info trace.CoordinationSessionKeepAliveTimeoutInfo) {
+		onState("lost")
+	}
+	t.OnSessionClientTimeout = func(info trace.CoordinationSessionClientTimeoutInfo) {
+		onState("lost")
+	}
+	t.OnSessionServerExpire = func(info trace.CoordinationSessionServerExpireInfo) {
+		onState("expired")
+	}
+	t.OnSessionStopped = func(info trace.CoordinationSessionStoppedInfo) {
+		onState("stopped")
+	}
+
+	return t
+}
+
+func (m *coordinationMetrics) streamInterceptor(config metrics.Config) grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil || method != Ydb_Coordination_V1.CoordinationService_Session_FullMethodName ||
+			config.Details()&trace.CoordinationEvents == 0 {
+			return stream, err
+		}
+
+		return &coordinationStream{
+			ClientStream: stream,
+			metrics:      m,
+		}, nil
+	}
+}
+
+// session returns tracked session, creates it if session is not tracked yet. Must be called under lock
+func (m *coordinationMetrics) session(id uint64, path string) *coordinationSession {
+	s, has := m.sessions[id]
+	if !has {
+		s = &coordinationSession{
+			path:     path,
+			held:     make(map[string]struct{}),
+			requests: make(map[uint64]coordinationRequest),
+		}
+		m.sessions[id] = s
+	}
+
+	return s
+}
+
+// request tracks request of session. ydb-go-sdk resends pending requests with new request ID
+// after reconnect, and session has at most one pending request of each kind for semaphore, so
+// resent request replaces pending one and keeps its start time
+func (m *coordinationMetrics) request(sessionID uint64, path string, reqID uint64, name string, acquire bool) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	s := m.session(sessionID, path)
+	r := coordinationRequest{
+		name:    name,
+		acquire: acquire,
+		start:   time.Now(),
+	}
+	for id, pending := range s.requests {
+		if pending.name == name && pending.acquire == acquire {
+			delete(s.requests, id)
+			r.start = pending.start
+		}
+	}
+	s.requests[reqID] = r
+}
+
+func (m *coordinationMetrics) acquired(
+	sessionID uint64, result *Ydb_Coordination.SessionResponse_AcquireSemaphoreResult,
+) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	s, has := m.sessions[sessionID]
+	if !has {
+		return
+	}
+	r, has := s.requests[result.GetReqId()]
+	if !has {
+		return
+	}
+	delete(s.requests, result.GetReqId())
+	outcome := "acquired"
+	switch {
+	case result.GetStatus() != Ydb.StatusIds_SUCCESS:
+		outcome = "error"
+	case !result.GetAcquired():
+		outcome = "timeout"
+	default:
+		if _, has := s.held[r.name]; !has {
+			s.held[r.name] = struct{}{}
+			m.held.With(map[string]string{
+				"path": s.path,
+			}).Add(1)
+		}
+	}
+	m.acquireLatency.With(map[string]string{
+		"path": s.path,
+	}).Record(time.Since(r.start))
+	m.acquires.With(map[string]string{
+		"path":    s.path,
+		"outcome": outcome,
+	}).Inc()
+}
+
+func (m *coordinationMetrics) released(
+	sessionID uint64, result *Ydb_Coordination.SessionResponse_ReleaseSemaphoreResult,
+) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	s, has := m.sessions[sessionID]
+	if !has {
+		return
+	}
+	r, has := s.requests[result.GetReqId()]
+	if !has {
+		return
+	}
+	delete(s.requests, result.GetReqId())
+	outcome := "released"
+	switch {
+	case result.GetStatus() != Ydb.StatusIds_SUCCESS:
+		outcome = "error"
+	case !result.GetReleased():
+		outcome = "not_held"
+	default:
+		if _, has := s.held[r.name]; has {
+			delete(s.held, r.name)
+			m.held.With(map[string]string{
+				"path": s.path,
+			}).Add(-1)
+		}
+	}
+	// release of semaphore with pending acquire cancels acquire. Cancel is resent after reconnect
+	// instead of acquire, so result of acquire may never be received
+	for id, pending := range s.requests {
+		if pending.acquire && pending.name == r.name {
+			delete(s.requests, id)
+			m.canceled(s.path, pending)
+		}
+	}
+	m.releases.With(map[string]string{
+		"path":    s.path,
+		"outcome": outcome,
+	}).Inc()
+}
+
+// canceled records request which has no result because session is closed or request is canceled.
+// Must be called under lock
+func (m *coordinationMetrics) canceled(path string, r coordinationRequest) {
+	labels := map[string]string{
+		"path":    path,
+		"outcome": "canceled",
+	}
+	if !r.acquire {
+		m.releases.With(labels).Inc()
+
+		return
+	}
+	m.acquireLatency.With(map[string]string{
+		"path": path,
+	}).Record(time.Since(r.start))
+	m.acquires.With(labels).Inc()
+}
+
+// closed records pending requests of session as canceled and forgets session and semaphores
+// held by session
+func (m *coordinationMetrics) closed(sessionID uint64) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	s, has := m.sessions[sessionID]
+	if !has {
+		return
+	}
+	delete(m.sessions, sessionID)
+	for _, r := range s.requests {
+		m.canceled(s.path, r)
+	}
+	if len(s.held) > 0 {
+		m.held.With(map[string]string{
+			"path": s.path,
+		}).Add(-float64(len(s.held)))
+	}
+}
+
+// coordinationStream observes messages of coordination session stream. Node path of stream is known
+// from session start request, session ID is known from session start request of reconnected session
+// or from session started response. Requests and held semaphores are tracked by session, not by
+// stream, so stream errors are not recorded: session is forgotten on session stopped response or on
+// failure which terminates session. Session sends and receives messages from different goroutines,
+// so path and session ID are guarded by mutex
+type coordinationStream struct {
+	grpc.ClientStream
+
+	metrics *coordinationMetrics
+
+	m         sync.Mutex
+	path      string
+	sessionID uint64
+}
+
+func (s *coordinationStream) SendMsg(m interface{}) error {
+	if msg, ok := m.(*Ydb_Coordination.SessionRequest); ok {
+		switch {
+		case msg.GetSessionStart() != nil:
+			s.m.Lock()
+			s.path = msg.GetSessionStart().GetPath()
+			s.sessionID = msg.GetSessionStart().GetSessionId()
+			s.m.Unlock()
+		case msg.GetAcquireSemaphore() != nil:
+			s.request(msg.GetAcquireSemaphore().GetReqId(), msg.GetAcquireSemaphore().GetName(), true)
+		case msg.GetReleaseSemaphore() != nil:
+			s.request(msg.GetReleaseSemaphore().GetReqId(), msg.GetReleaseSemaphore().GetName(), false)
+		}
+	}
+
+	return s.ClientStream.SendMsg(m)
+}
+
+// request tracks request of session. Session sends requests only after session is started, so
+// requests without session ID are not tracked
+func (s *coordinationStream) request(reqID uint64, name string, acquire bool) {
+	s.m.Lock()
+	path, sessionID := s.path, s.sessionID
+	s.m.Unlock()
+	if sessionID == 0 {
+		return
+	}
+	s.metrics.request(sessionID, path, reqID, name, acquire)
+}
+
+func (s *coordinationStream) session() uint64 {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.sessionID
+}
+
+func (s *coordinationStream) RecvMsg(m interface{}) error {
+	if err := s.ClientStream.RecvMsg(m); err != nil {
+		return err
+	}
+	msg, ok := m.(*Ydb_Coordination.SessionResponse)
+	if !ok {
+		return nil
+	}
+	switch {
+	case msg.GetSessionStarted() != nil:
+		s.m.Lock()
+		s.sessionID = msg.GetSessionStarted().GetSessionId()
+		s.m.Unlock()
+	case msg.GetAcquireSemaphoreResult() != nil:
+		s.metrics.acquired(s.session(), msg.GetAcquireSemaphoreResult())
+	case msg.GetReleaseSemaphoreResult() != nil:
+		s.metrics.released(s.session(), msg.GetReleaseSemaphoreResult())
+	case msg.GetSessionStopped() != nil:
+		s.metrics.closed(msg.GetSessionStopped().GetSessionId())
+	case msg.GetFailure() != nil:
+		switch msg.GetFailure().GetStatus() {
+		case Ydb.StatusIds_SESSION_EXPIRED, Ydb.StatusIds_UNAUTHORIZED, Ydb.StatusIds_NOT_FOUND:
+			s.metrics.closed(s.session())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,251 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb_Coordination"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+type testCoordinationStream struct {
+	grpc.ClientStream
+
+	responses []*Ydb_Coordination.SessionResponse
+}
+
+func (s *testCoordinationStream) SendMsg(interface{}) error { return nil }
+
+// RecvMsg returns io.ErrUnexpectedEOF after all responses
+func (s *testCoordinationStream) RecvMsg(m interface{}) error {
+	if len(s.responses) == 0 {
+		return io.ErrUnexpectedEOF
+	}
+	proto.Merge(m.(proto.Message), s.responses[0])
+	s.responses = s.responses[1:]
+
+	return nil
+}
+
+func TestCoordinationTrace(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	config := Config(registry).WithSystem("ydb")
+	tt := newCoordinationMetrics(config).trace(config)
+
+	tt.OnSessionStarted(trace.CoordinationSessionStartedInfo{})
+	tt.OnSessionKeepAliveTimeout(trace.CoordinationSessionKeepAliveTimeoutInfo{})
+	tt.OnSessionClientTimeout(trace.CoordinationSessionClientTimeoutInfo{})
+	tt.OnSessionServerExpire(trace.CoordinationSessionServerExpireInfo{})
+
+	for state, expected := range map[string]float64{"connected": 1, "lost": 2, "expired": 1} {
+		assertValue(t, registry, "ydb_go_sdk_ydb_coordination_session_states", map[string]string{"state": state}, expected)
+	}
+}
+
+func TestCoordinationStream(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := newCoordinationMetrics(Config(registry).WithSystem("ydb"))
+
+	stream := &coordinationStream{
+		ClientStream: &testCoordinationStream{responses: []*Ydb_Coordination.SessionResponse{
+			{Response: &Ydb_Coordination.SessionResponse_SessionStarted_{
+				SessionStarted: &Ydb_Coordination.SessionResponse_SessionStarted{SessionId: 42},
+			}},
+			{Response: &Ydb_Coordination.SessionResponse_AcquireSemaphoreResult_{
+				AcquireSemaphoreResult: &Ydb_Coordination.SessionResponse_AcquireSemaphoreResult{
+					ReqId: 1, Status: Ydb.StatusIds_SUCCESS, Acquired: true,
+				},
+			}},
+			{Response: &Ydb_Coordination.SessionResponse_AcquireSemaphoreResult_{
+				AcquireSemaphoreResult: &Ydb_Coordination.SessionResponse_AcquireSemaphoreResult{
+					ReqId: 2, Status: Ydb.StatusIds_SUCCESS, Acquired: true,
+				},
+			}},
+			{Response: &Ydb_Coordination.SessionResponse_AcquireSemaphoreResult_{
+				AcquireSemaphoreResult: &Ydb_Coordination.SessionResponse_AcquireSemaphoreResult{
+					ReqId: 3, Status: Ydb.StatusIds_SUCCESS, Acquired: false,
+				},
+			}},
+			{Response: &Ydb_Coordination.SessionResponse_ReleaseSemaphoreResult_{
+				ReleaseSemaphoreResult: &Ydb_Coordination.SessionResponse_ReleaseSemaphoreResult{
+					ReqId: 4, Status: Ydb.StatusIds_SUCCESS, Released: true,
+				},
+			}},
+			{Response: &Ydb_Coordination.SessionResponse_Failure_{
+				Failure: &Ydb_Coordination.SessionResponse_Failure{Status: Ydb.StatusIds_SESSION_EXPIRED},
+			}},
+		}},
+		metrics: m,
+	}
+	send := func(request *Ydb_Coordination.SessionRequest) {
+		if err := stream.SendMsg(request); err != nil {
+			t.Fatal(err)
+		}
+	}
+	recv := func() {
+		if err := stream.RecvMsg(&Ydb_Coordination.SessionResponse{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	acquire := func(reqID uint64, name string) *Ydb_Coordination.SessionRequest {
+		return &Ydb_Coordination.SessionRequest{Request: &Ydb_Coordination.SessionRequest_AcquireSemaphore_{
+			AcquireSemaphore: &Ydb_Coordination.SessionRequest_AcquireSemaphore{ReqId: reqID, Name: name},
+		}}
+	}
+	path := map[string]string{"path": "/local/election"}
+
+	send(&Ydb_Coordination.SessionRequest{Request: &Ydb_Coordination.SessionRequest_SessionStart_{
+		SessionStart: &Ydb_Coordination.SessionRequest_SessionStart{Path: "/local/election"},
+	}})
+	recv()
+	send(acquire(1, "leader"))
+	send(acquire(2, "lock"))
+	send(acquire(3, "other"))
+	recv()
+	recv()
+	recv()
+	assertValue(t, registry, "ydb_go_sdk_ydb_coordination_semaphore_held", path, 2)
+
+	send(&Ydb_Coordination.SessionRequest{Request: &Ydb_Coordination.SessionRequest_ReleaseSemaphore_{
+		ReleaseSemaphore: &Ydb_Coordination.SessionRequest_ReleaseSemaphore{ReqId: 4, Name: "lock"},
+	}})
+	recv()
+	assertValue(t, registry, "ydb_go_sdk_ydb_coordination_semaphore_held", path, 1)
+
+	recv()
+	assertValue(t, registry, "ydb_go_sdk_ydb_coordination_semaphore_held", path, 0)
+
+	assertValue(t, registry, "ydb_go_sdk_ydb_coordination_semaphore_acquire_latency", path, 3)
+	assertValue(t, registry, "ydb_go_sdk_ydb_coordination_semaphore_acquires", map[string]string{
+		"path": "/local/election", "outcome": "acquired",
+	}, 2)
+	assertValue(t, registry, "ydb_go_sdk_ydb_coordination_semaphore_acquires", map[string]string{
+		"path": "/local/election", "outcome": "timeout",
+	}, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_coordination_semaphore_releases", map[string]string{
+		"path": "/local/election", "outcome": "released",
+	}, 1)
+}
+
+func TestCoordinationStreamReconnect(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := newCoordinationMetrics(Config(registry).WithSystem("ydb"))
+
+	acquire := func(reqID uint64, name string) *Ydb_Coordination.SessionRequest {
+		return &Ydb_Coordination.SessionRequest{Request: &Ydb_Coordination.SessionRequest_AcquireSemaphore_{
+			AcquireSemaphore: &Ydb_Coordination.SessionRequest_AcquireSemaphore{ReqId: reqID, Name: name},
+		}}
+	}
+	release := func(reqID uint64, name string) *Ydb_Coordination.SessionRequest {
+		return &Ydb_Coordination.SessionRequest{Request: &Ydb_Coordination.SessionRequest_ReleaseSemaphore_{
+			ReleaseSemaphore: &Ydb_Coordination.SessionRequest_ReleaseSemaphore{ReqId: reqID, Name: name},
+		}}
+	}
+	start := func(sessionID uint64) *Ydb_Coordination.SessionRequest {
+		return &Ydb_Coordination.SessionRequest{Request: &Ydb_Coordination.SessionRequest_SessionStart_{
+			SessionStart: &Ydb_Coordination.SessionRequest_SessionStart{Path: "/local/election", SessionId: sessionID},
+		}}
+	}
+	started := &Ydb_Coordination.SessionResponse{Response: &Ydb_Coordination.SessionResponse_SessionStarted_{
+		SessionStarted: &Ydb_Coordination.SessionResponse_SessionStarted{SessionId: 42},
+	}}
+	acquired := func(reqID uint64) *Ydb_Coordination.SessionResponse {
+		return &Ydb_Coordination.SessionResponse{Response: &Ydb_Coordination.SessionResponse_AcquireSemaphoreResult_{
+			AcquireSemaphoreResult: &Ydb_Coordination.SessionResponse_AcquireSemaphoreResult{
+				ReqId: reqID, Status: Ydb.StatusIds_SUCCESS, Acquired: true,
+			},
+		}}
+	}
+	send := func(stream *coordinationStream, requests ...*Ydb_Coordination.SessionRequest) {
+		for _, request := range requests {
+			if err := stream.SendMsg(request); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	recv := func(stream *coordinationStream, n int) {
+		for i := 0; i < n; i++ {
+			if err := stream.RecvMsg(&Ydb_Coordination.SessionResponse{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	path := map[string]string{"path": "/local/election"}
+	outcome := func(outcome string) map[string]string {
+		return map[string]string{"path": "/local/election", "outcome": outcome}
+	}
+
+	first := &coordinationStream{
+		ClientStream: &testCoordinationStream{responses: []*Ydb_Coordination.SessionResponse{
+			started,
+			acquired(1),
+		}},
+		metrics: m,
+	}
+	send(first, start(0))
+	recv(first, 1)
+	send(first, acquire(1, "leader"), acquire(2, "lock"), release(3, "other"))
+	recv(first, 1)
+	// session stream broken without SessionStopped and Failure
+	if err := first.RecvMsg(&Ydb_Coordination.SessionResponse{}); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertValue(t, registry, "ydb_go_sdk_ydb_coordination_semaphore_held", path, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_coordination_semaphore_acquires", outcome("canceled"), 0)
+
+	// session is reconnected on new stream, pending requests are resent with new request IDs
+	second := &coordinationStream{
+		ClientStream: &testCoordinationStream{responses: []*Ydb_Coordination.SessionResponse{
+			started,
+			acquired(4),
+		}},
+		metrics: m,
+	}
+	// session ID is known from session start request, so requests are tracked before session started response
+	send(second, start(42), acquire(4, "lock"), release(5, "other"))
+	recv(second, 2)
+	assertValue(t, registry, "ydb_go_sdk_ydb_coordination_semaphore_held", path, 2)
+	assertValue(t, registry, "ydb_go_sdk_ydb_coordination_semaphore_acquires", outcome("acquired"), 2)
+	assertValue(t, registry, "ydb_go_sdk_ydb_coordination_semaphore_acquire_latency", path, 2)
+
+	// session stopped, so pending release is canceled and held semaphores are released
+	second.ClientStream.(*testCoordinationStream).responses = []*Ydb_Coordination.SessionResponse{
+		{Response: &Ydb_Coordination.SessionResponse_SessionStopped_{
+			SessionStopped: &Ydb_Coordination.SessionResponse_SessionStopped{SessionId: 42},
+		}},
+	}
+	recv(second, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_coordination_semaphore_held", path, 0)
+	assertValue(t, registry, "ydb_go_sdk_ydb_coordination_semaphore_acquires", outcome("canceled"), 0)
+	assertValue(t, registry, "ydb_go_sdk_ydb_coordination_semaphore_releases", outcome("canceled"), 1)
+	if len(m.sessions) != 0 {
+		t.Fatalf("sessions leaked: %v", m.sessions)
+	}
+}
+
+func TestCoordinationCanceledAcquire(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := newCoordinationMetrics(Config(registry).WithSystem("ydb"))
+
+	m.request(42, "/local/election", 1, "lock", true)
+	// acquire is canceled by release of same semaphore
+	m.request(42, "/local/election", 2, "lock", false)
+	m.released(42, &Ydb_Coordination.SessionResponse_ReleaseSemaphoreResult{
+		ReqId: 2, Status: Ydb.StatusIds_SUCCESS, Released: false,
+	})
+
+	assertValue(t, registry, "ydb_go_sdk_ydb_coordination_semaphore_acquires", map[string]string{
+		"path": "/local/election", "outcome": "canceled",
+	}, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_coordination_semaphore_releases", map[string]string{
+		"path": "/local/election", "outcome": "not_held",
+	}, 1)
+	if requests := m.sessions[42].requests; len(requests) != 0 {
+		t.Fatalf("requests leaked: %v", requests)
+	}
+}
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/metrics"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+)
+
+// credentials makes driver trace with metrics of getting token from credentials.
+// Expiration of token exported as unix time, so time left is computed at query time
+// (for example, token_expires_at_seconds - time()). Token without exp claim resets
+// expiration to zero
+func credentials(config metrics.Config) (t trace.Driver) {
+	config = config.WithSystem("driver").WithSystem("credentials")
+	latency := config.TimerVec("latency", "status")
+	errs := config.CounterVec("errors", "type")
+	expires := config.GaugeVec("token_expires_at_seconds")
+
+	var (
+		mu        sync.Mutex
+		lastToken uint64
+		expiresAt time.Time
+	)
+	t.OnGetCredentials = func(info trace.DriverGetCredentialsStartInfo) func(trace.DriverGetCredentialsDoneInfo) {
+		if config.Details()&trace.DriverCredentialsEvents == 0 {
+			return nil
+		}
+		start := time.Now()
+
+		return func(info trace.DriverGetCredentialsDoneInfo) {
+			latency.With(map[string]string{
+				"status": errorBrief(info.Error),
+			}).Record(time.Since(start))
+			if info.Error != nil {
+				errs.With(map[string]string{
+					"type": errorType(info.Error),
+				}).Inc()
+
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			// token hashed for not keeping token in memory of adapter
+			if h := hash(info.Token); h != lastToken {
+				lastToken = h
+				expiresAt, _ = tokenExpiresAt(info.Token)
+			}
+			if expiresAt.IsZero() {
+				expires.With(nil).Set(0)
+			} else {
+				expires.With(nil).Set(float64(expiresAt.Unix()))
+			}
+		}
+	}
+
+	return t
+}
+
+// errorType returns errorBrief or type of root cause of error if error is not a ydb error
+func errorType(err error) string {
+	if brief := errorBrief(err); brief != "unknown" {
+		return brief
+	}
+	for {
+		cause := errors.Unwrap(err)
+		if cause == nil {
+			return fmt.Sprintf("%T", err)
+		}
+		err = cause
+	}
+}
+
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+
+	return h.Sum64()
+}
+
+// tokenExpiresAt returns expiration time from exp claim of JWT token
+func tokenExpiresAt(token string) (time.Time, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, errors.New("token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, err
+	}
+	var claims struct {
+		Exp *float64 `json:"exp"`
+	}
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, err
+	}
+	if claims.Exp == nil {
+		return time.Time{}, errors.New("token has no exp claim")
+	}
+
+	return time.Unix(int64(*claims.Exp), 0), nil
+}
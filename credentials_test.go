@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+
+	"github.com/ydb-platform/ydb-go-sdk-prometheus/v2/ydbpromtest"
+)
+
+type testCredentialsError struct{}
+
+func (testCredentialsError) Error() string { return "test" }
+
+func TestCredentials(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	d := credentials(Config(registry).WithSystem("ydb"))
+
+	exp := time.Now().Add(time.Hour).Unix()
+	token := "header." + base64.RawURLEncoding.EncodeToString(
+		[]byte(fmt.Sprintf(`{"exp":%d}`, exp)),
+	) + ".signature"
+	d.OnGetCredentials(trace.DriverGetCredentialsStartInfo{})(trace.DriverGetCredentialsDoneInfo{Token: token})
+	d.OnGetCredentials(trace.DriverGetCredentialsStartInfo{})(trace.DriverGetCredentialsDoneInfo{
+		Error: fmt.Errorf("wrapped: %w", testCredentialsError{}),
+	})
+
+	assertValue(t, registry, "ydb_go_sdk_ydb_driver_credentials_latency", map[string]string{"status": "OK"}, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_driver_credentials_errors", map[string]string{
+		"type": "metrics.testCredentialsError",
+	}, 1)
+	assertExpiresAt(t, registry, float64(exp))
+
+	// token without exp claim resets expiration of previous token
+	d.OnGetCredentials(trace.DriverGetCredentialsStartInfo{})(trace.DriverGetCredentialsDoneInfo{
+		Token: "not-a-jwt",
+	})
+	assertExpiresAt(t, registry, 0)
+}
+
+func assertExpiresAt(t *testing.T, registry prometheus.Gatherer, expected float64) {
+	t.Helper()
+
+	expiresAt, err := ydbpromtest.GaugeValue(registry, "ydb_go_sdk_ydb_driver_credentials_token_expires_at_seconds", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expiresAt != expected {
+		t.Fatalf("unexpected token expiration: %v, expected %v", expiresAt, expected)
+	}
+}
+
+func TestErrorType(t *testing.T) {
+	for _, tt := range []struct {
+		err      error
+		expected string
+	}{
+		{err: errors.New("test"), expected: "*errors.errorString"},
+		{err: fmt.Errorf("wrapped: %w", testCredentialsError{}), expected: "metrics.testCredentialsError"},
+	} {
+		if got := errorType(tt.err); got != tt.expected {
+			t.Errorf("unexpected type of %v: %q, expected %q", tt.err, got, tt.expected)
+		}
+	}
+}
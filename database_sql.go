@@ -0,0 +1,169 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/metrics"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+)
+
+var (
+	defaultStatementsBuckets = []float64{0, 1, 2, 3, 5, 10, 20, 50, 100}
+)
+
+type sqlConnCreatedKey struct{}
+
+// sqlTx is a tracked transaction of database/sql
+type sqlTx struct {
+	start      time.Time
+	statements int
+}
+
+// sqlNeverUsed is a last usage of connection of database/sql which is not used yet. Connection of
+// ydb-go-sdk keeps last usage in unix seconds (zero before first usage) and reports idle time as
+// time.Since(time.Unix(lastUsage, 0)), so idle time of first usage is a time since unix epoch
+var sqlNeverUsed = time.Unix(0, 0)
+
+// sqlIdleTime returns idle time of connection before reuse, first usage of connection is skipped.
+// Last usage of connection has resolution of one second, so idle time less than second can be zero
+func sqlIdleTime(idle time.Duration) (time.Duration, bool) {
+	// last usage restored from idle time differs from stored one by time between computing of idle time and now
+	if d := time.Now().Add(-idle).Sub(sqlNeverUsed); d > -time.Second && d < time.Second {
+		return 0, false
+	}
+
+	return idle, true
+}
+
+// databaseSQL makes database/sql trace with metrics of connections and transactions
+//
+//nolint:funlen
+func databaseSQL(config metrics.Config) (t trace.DatabaseSQL) {
+	config = config.WithSystem("database").WithSystem("sql")
+	conns := config.WithSystem("conns")
+	lifetime := conns.TimerVec("lifetime")
+	idleTime := conns.TimerVec("idle_time", "query_mode")
+	txConfig := config.WithSystem("tx")
+	duration := txConfig.TimerVec("duration", "outcome", "status")
+	statements := txConfig.HistogramVec("statements", defaultStatementsBuckets, "outcome")
+
+	var (
+		mu  sync.Mutex
+		txs = make(map[interface{ ID() string }]*sqlTx)
+	)
+
+	// trace of closing of connection has no information about connection, so time of creating is stored
+	// in context of connector, which is kept by connection until close
+	t.OnConnectorConnect = func(info trace.DatabaseSQLConnectorConnectStartInfo) func(
+		trace.DatabaseSQLConnectorConnectDoneInfo,
+	) {
+		if info.Context != nil && *info.Context != nil {
+			*info.Context = context.WithValue(*info.Context, sqlConnCreatedKey{}, time.Now())
+		}
+
+		return nil
+	}
+	t.OnConnClose = func(info trace.DatabaseSQLConnCloseStartInfo) func(trace.DatabaseSQLConnCloseDoneInfo) {
+		if info.Context == nil || *info.Context == nil || config.Details()&trace.DatabaseSQLConnectorEvents == 0 {
+			return nil
+		}
+		if created, ok := (*info.Context).Value(sqlConnCreatedKey{}).(time.Time); ok {
+			lifetime.With(nil).Record(time.Since(created))
+		}
+
+		return nil
+	}
+	onIdle := func(mode string, idle time.Duration) {
+		if config.Details()&trace.DatabaseSQLConnEvents == 0 {
+			return
+		}
+		if idle, ok := sqlIdleTime(idle); ok {
+			idleTime.With(map[string]string{
+				"query_mode": mode,
+			}).Record(idle)
+		}
+	}
+	t.OnConnExec = func(info trace.DatabaseSQLConnExecStartInfo) func(trace.DatabaseSQLConnExecDoneInfo) {
+		onIdle(info.Mode, info.IdleTime)
+
+		return nil
+	}
+	t.OnConnQuery = func(info trace.DatabaseSQLConnQueryStartInfo) func(trace.DatabaseSQLConnQueryDoneInfo) {
+		onIdle(info.Mode, info.IdleTime)
+
+		return nil
+	}
+	t.OnConnBegin = func(info trace.DatabaseSQLConnBeginStartInfo) func(trace.DatabaseSQLConnBeginDoneInfo) {
+		if config.Details()&trace.DatabaseSQLTxEvents == 0 {
+			return nil
+		}
+		start := time.Now()
+
+		return func(info trace.DatabaseSQLConnBeginDoneInfo) {
+			if info.Error != nil || info.Tx == nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			txs[info.Tx] = &sqlTx{start: start}
+		}
+	}
+	onStatement := func(tx interface{ ID() string }) {
+		mu.Lock()
+		defer mu.Unlock()
+		if s, has := txs[tx]; has {
+			s.statements++
+		}
+	}
+	t.OnTxExec = func(info trace.DatabaseSQLTxExecStartInfo) func(trace.DatabaseSQLTxExecDoneInfo) {
+		onStatement(info.Tx)
+
+		return nil
+	}
+	t.OnTxQuery = func(info trace.DatabaseSQLTxQueryStartInfo) func(trace.DatabaseSQLTxQueryDoneInfo) {
+		onStatement(info.Tx)
+
+		return nil
+	}
+	onEnd := func(tx interface{ ID() string }, outcome string) func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		s, has := txs[tx]
+		if !has {
+			return nil
+		}
+		delete(txs, tx)
+
+		return func(err error) {
+			duration.With(map[string]string{
+				"outcome": outcome,
+				"status":  errorBrief(err),
+			}).Record(time.Since(s.start))
+			statements.With(map[string]string{
+				"outcome": outcome,
+			}).Record(float64(s.statements))
+		}
+	}
+	t.OnTxCommit = func(info trace.DatabaseSQLTxCommitStartInfo) func(trace.DatabaseSQLTxCommitDoneInfo) {
+		if done := onEnd(info.Tx, "commit"); done != nil {
+			return func(info trace.DatabaseSQLTxCommitDoneInfo) {
+				done(info.Error)
+			}
+		}
+
+		return nil
+	}
+	t.OnTxRollback = func(info trace.DatabaseSQLTxRollbackStartInfo) func(trace.DatabaseSQLTxRollbackDoneInfo) {
+		if done := onEnd(info.Tx, "rollback"); done != nil {
+			return func(info trace.DatabaseSQLTxRollbackDoneInfo) {
+				done(info.Error)
+			}
+		}
+
+		return nil
+	}
+
+	return t
+}
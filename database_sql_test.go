@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+
+	"github.com/ydb-platform/ydb-go-sdk-prometheus/v2/ydbpromtest"
+)
+
+type testTx struct {
+	id string
+}
+
+func (tx *testTx) ID() string {
+	return tx.id
+}
+
+func TestDatabaseSQLConns(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	d := databaseSQL(Config(registry).WithSystem("ydb"))
+
+	ctx := context.Background()
+	d.OnConnectorConnect(trace.DatabaseSQLConnectorConnectStartInfo{Context: &ctx})
+	d.OnConnQuery(trace.DatabaseSQLConnQueryStartInfo{Mode: "data", IdleTime: time.Since(time.Unix(0, 0))})
+	d.OnConnQuery(trace.DatabaseSQLConnQueryStartInfo{Mode: "data", IdleTime: time.Second})
+	d.OnConnExec(trace.DatabaseSQLConnExecStartInfo{Mode: "scheme", IdleTime: time.Second})
+	d.OnConnClose(trace.DatabaseSQLConnCloseStartInfo{Context: &ctx})
+
+	assertValue(t, registry, "ydb_go_sdk_ydb_database_sql_conns_lifetime", nil, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_database_sql_conns_idle_time", map[string]string{"query_mode": "data"}, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_database_sql_conns_idle_time", map[string]string{"query_mode": "scheme"}, 1)
+}
+
+func TestDatabaseSQLTx(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	d := databaseSQL(Config(registry).WithSystem("ydb"))
+
+	begin := func(tx *testTx) {
+		d.OnConnBegin(trace.DatabaseSQLConnBeginStartInfo{})(trace.DatabaseSQLConnBeginDoneInfo{Tx: tx})
+	}
+	committed, rolledBack := &testTx{id: "FAKE"}, &testTx{id: "FAKE"}
+	begin(committed)
+	begin(rolledBack)
+	d.OnTxExec(trace.DatabaseSQLTxExecStartInfo{Tx: committed})
+	d.OnTxQuery(trace.DatabaseSQLTxQueryStartInfo{Tx: committed})
+	d.OnTxQuery(trace.DatabaseSQLTxQueryStartInfo{Tx: rolledBack})
+	d.OnTxCommit(trace.DatabaseSQLTxCommitStartInfo{Tx: committed})(
+		trace.DatabaseSQLTxCommitDoneInfo{Error: context.Canceled},
+	)
+	d.OnTxRollback(trace.DatabaseSQLTxRollbackStartInfo{Tx: rolledBack})(trace.DatabaseSQLTxRollbackDoneInfo{})
+
+	assertValue(t, registry, "ydb_go_sdk_ydb_database_sql_tx_duration",
+		map[string]string{"outcome": "commit", "status": "context/Canceled"}, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_database_sql_tx_duration",
+		map[string]string{"outcome": "rollback", "status": "OK"}, 1)
+
+	for outcome, expected := range map[string]float64{"commit": 2, "rollback": 1} {
+		sum, err := ydbpromtest.HistogramSum(registry, "ydb_go_sdk_ydb_database_sql_tx_statements",
+			map[string]string{"outcome": outcome},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sum != expected {
+			t.Errorf("unexpected statements of %s: %v, expected %v", outcome, sum, expected)
+		}
+	}
+}
+
+func TestSQLIdleTime(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		idle time.Duration
+		ok   bool
+	}{
+		{name: "reused", idle: 3 * time.Second, ok: true},
+		{name: "reused within second", idle: 0, ok: true},
+		{name: "never used", idle: time.Since(time.Unix(0, 0)), ok: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			idle, ok := sqlIdleTime(tt.idle)
+			if ok != tt.ok || (ok && idle != tt.idle) {
+				t.Fatalf("unexpected idle time: %v, %v", idle, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// dbStatsCollector collects statistics of connections pool of database/sql
+type dbStatsCollector struct {
+	db *sql.DB
+
+	maxOpen           *prometheus.Desc
+	open              *prometheus.Desc
+	inUse             *prometheus.Desc
+	idle              *prometheus.Desc
+	waitCount         *prometheus.Desc
+	waitDuration      *prometheus.Desc
+	closedMaxIdle     *prometheus.Desc
+	closedMaxIdleTime *prometheus.Desc
+	closedMaxLifetime *prometheus.Desc
+}
+
+func newDBStatsCollector(c *config, db *sql.DB) *dbStatsCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(c.namespace, "", name), help, nil, c.constLabels)
+	}
+
+	return &dbStatsCollector{
+		db:                db,
+		maxOpen:           desc("pool_max_open", "Maximum number of open connections to the database."),
+		open:              desc("pool_open", "The number of established connections both in use and idle."),
+		inUse:             desc("pool_in_use", "The number of connections currently in use."),
+		idle:              desc("pool_idle", "The number of idle connections."),
+		waitCount:         desc("pool_wait_count", "The total number of connections waited for."),
+		waitDuration:      desc("pool_wait_duration_seconds", "The total time blocked waiting for a new connection."),
+		closedMaxIdle:     desc("pool_closed_max_idle", "The total number of connections closed due to SetMaxIdleConns."),
+		closedMaxIdleTime: desc("pool_closed_max_idle_time", "The total number of connections closed due to idle time."),
+		closedMaxLifetime: desc("pool_closed_max_lifetime", "The total number of connections closed due to lifetime."),
+	}
+}
+
+func (c *dbStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxOpen
+	ch <- c.open
+	ch <- c.inUse
+	ch <- c.idle
+	ch <- c.waitCount
+	ch <- c.waitDuration
+	ch <- c.closedMaxIdle
+	ch <- c.closedMaxIdleTime
+	ch <- c.closedMaxLifetime
+}
+
+func (c *dbStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.db.Stats()
+	ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(stats.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(stats.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.closedMaxIdle, prometheus.CounterValue, float64(stats.MaxIdleClosed))
+	ch <- prometheus.MustNewConstMetric(c.closedMaxIdleTime, prometheus.CounterValue, float64(stats.MaxIdleTimeClosed))
+	ch <- prometheus.MustNewConstMetric(c.closedMaxLifetime, prometheus.CounterValue, float64(stats.MaxLifetimeClosed))
+}
+
+// RegisterDBStats registers collector of statistics of connections pool of database/sql (sql.DBStats)
+// in registry. Metrics are named same as vectors of adapter next to database_sql metrics of ydb-go-sdk
+// (for example, ydb_go_sdk_ydb_database_sql_pool_wait_count) and have const labels of adapter.
+// Options are applied without side effects: RegisterDBStats does not publish to expvar.
+// Unlike vectors of adapter, statistics of other db can't be shared, so RegisterDBStats returns
+// prometheus.AlreadyRegisteredError if statistics with same names and const labels already registered.
+// Several databases can be registered in same registry with different const labels (WithConstLabels).
+// On error collector is unregistered from all registerers, where it was registered
+func RegisterDBStats(registry prometheus.Registerer, db *sql.DB, opts ...option) error {
+	c := newConfig(registry, opts...).withSystem("ydb").withSystem("database").withSystem("sql")
+	collector := newDBStatsCollector(c, db)
+	registerers := append([]prometheus.Registerer{c.registry}, c.registerers...)
+	for i, r := range registerers {
+		if err := r.Register(collector); err != nil {
+			for _, registered := range registerers[:i] {
+				registered.Unregister(collector)
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
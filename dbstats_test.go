@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	sqlDriver "database/sql/driver"
+	"errors"
+	"expvar"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type testConnector struct{}
+
+func (testConnector) Connect(context.Context) (sqlDriver.Conn, error) {
+	return nil, errors.New("not connected")
+}
+
+func (testConnector) Driver() sqlDriver.Driver {
+	return nil
+}
+
+func TestRegisterDBStats(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	db := sql.OpenDB(testConnector{})
+	defer db.Close()
+	db.SetMaxOpenConns(5)
+
+	if err := RegisterDBStats(registry, db, WithConstLabels(prometheus.Labels{"service": "bench"})); err != nil {
+		t.Fatal(err)
+	}
+	assertValue(t, registry, "ydb_go_sdk_ydb_database_sql_pool_max_open", map[string]string{"service": "bench"}, 5)
+	assertValue(t, registry, "ydb_go_sdk_ydb_database_sql_pool_open", nil, 0)
+	assertValue(t, registry, "ydb_go_sdk_ydb_database_sql_pool_wait_count", nil, 0)
+
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	err := RegisterDBStats(registry, db, WithConstLabels(prometheus.Labels{"service": "bench"}))
+	if !errors.As(err, &alreadyRegistered) {
+		t.Errorf("unexpected error on second registration of stats with same names: %v", err)
+	}
+	if err := RegisterDBStats(registry, db, WithConstLabels(prometheus.Labels{"service": "other"})); err != nil {
+		t.Errorf("unexpected error on registration of stats with other const labels: %v", err)
+	}
+}
+
+func TestRegisterDBStatsAdditionalRegisterer(t *testing.T) {
+	registry, additional := prometheus.NewRegistry(), prometheus.NewRegistry()
+	db := sql.OpenDB(testConnector{})
+	defer db.Close()
+
+	if err := RegisterDBStats(additional, db); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterDBStats(registry, db, WithAdditionalRegisterer(additional)); err == nil {
+		t.Fatal("no error on registration in additional registerer")
+	}
+	// collector unregistered from main registry on error
+	if err := RegisterDBStats(registry, db); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConstLabels(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	config := Config(registry, WithConstLabels(prometheus.Labels{"service": "bench"})).WithSystem("ydb")
+	config.CounterVec("errors", "status").With(map[string]string{"status": "OK"}).Inc()
+
+	assertValue(t, registry, "ydb_go_sdk_ydb_errors", map[string]string{"service": "bench", "status": "OK"}, 1)
+}
+
+func TestRegisterDBStatsNames(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	db := sql.OpenDB(testConnector{})
+	defer db.Close()
+	db.SetMaxOpenConns(5)
+
+	Config(registry, WithSeparator("__")).WithSystem("ydb").WithSystem("database").WithSystem("sql").
+		GaugeVec("conns").With(nil).Set(1)
+	if err := RegisterDBStats(registry, db, WithSeparator("__"), WithExpvar("ydb_test_dbstats")); err != nil {
+		t.Fatal(err)
+	}
+	// stats are named same as vectors
+	assertValue(t, registry, "ydb_go_sdk__ydb__database__sql_conns", nil, 1)
+	assertValue(t, registry, "ydb_go_sdk__ydb__database__sql_pool_max_open", nil, 5)
+	if expvar.Get("ydb_test_dbstats") != nil {
+		t.Fatal("RegisterDBStats published expvar")
+	}
+}
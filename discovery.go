@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/metrics"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+)
+
+func discovery(config metrics.Config) (t trace.Discovery) {
+	config = config.WithSystem("discovery")
+	latency := config.TimerVec("latency", "status")
+	endpoints := config.HistogramVec("endpoints", []float64{0, 1, 2, 3, 5, 10, 20, 50, 100})
+
+	t.OnDiscover = func(info trace.DiscoveryDiscoverStartInfo) func(trace.DiscoveryDiscoverDoneInfo) {
+		start := time.Now()
+
+		return func(info trace.DiscoveryDiscoverDoneInfo) {
+			if config.Details()&trace.DiscoveryEvents != 0 {
+				latency.With(map[string]string{
+					"status": errorBrief(info.Error),
+				}).Record(time.Since(start))
+				if info.Error == nil {
+					endpoints.With(nil).Record(float64(len(info.Endpoints)))
+				}
+			}
+		}
+	}
+
+	return t
+}
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/metrics"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+)
+
+// driver makes driver trace with connection-level metrics which complement ydb-go-sdk driver metrics
+func driver(config metrics.Config) (t trace.Driver) {
+	config = config.WithSystem("driver").WithSystem("conn")
+	dialLatency := config.TimerVec("dial_latency", "endpoint", "status")
+	states := config.GaugeVec("states", "state")
+	bans := config.CounterVec("bans", "endpoint", "node_id", "cause")
+	allows := config.CounterVec("allows", "endpoint", "node_id")
+
+	knownStates := make(map[string]string)
+	statesMu := sync.Mutex{}
+	setState := func(endpoint, state string) {
+		statesMu.Lock()
+		defer statesMu.Unlock()
+		if prev, has := knownStates[endpoint]; has {
+			if prev == state {
+				return
+			}
+			states.With(map[string]string{
+				"state": prev,
+			}).Add(-1)
+		}
+		knownStates[endpoint] = state
+		states.With(map[string]string{
+			"state": state,
+		}).Add(1)
+	}
+
+	t.OnConnDial = func(info trace.DriverConnDialStartInfo) func(trace.DriverConnDialDoneInfo) {
+		endpoint := info.Endpoint.Address()
+		start := time.Now()
+
+		return func(info trace.DriverConnDialDoneInfo) {
+			if config.Details()&trace.DriverConnEvents != 0 {
+				dialLatency.With(map[string]string{
+					"endpoint": endpoint,
+					"status":   errorBrief(info.Error),
+				}).Record(time.Since(start))
+			}
+		}
+	}
+	t.OnConnStateChange = func(info trace.DriverConnStateChangeStartInfo) func(trace.DriverConnStateChangeDoneInfo) {
+		endpoint := info.Endpoint.Address()
+
+		return func(info trace.DriverConnStateChangeDoneInfo) {
+			if config.Details()&trace.DriverConnEvents != 0 && info.State != nil {
+				setState(endpoint, info.State.String())
+			}
+		}
+	}
+	t.OnConnBan = func(info trace.DriverConnBanStartInfo) func(trace.DriverConnBanDoneInfo) {
+		if config.Details()&trace.DriverConnEvents != 0 {
+			bans.With(map[string]string{
+				"endpoint": info.Endpoint.Address(),
+				"node_id":  idToString(info.Endpoint.NodeID()),
+				"cause":    errorBrief(info.Cause),
+			}).Inc()
+		}
+		endpoint := info.Endpoint.Address()
+
+		return func(info trace.DriverConnBanDoneInfo) {
+			if config.Details()&trace.DriverConnEvents != 0 && info.State != nil {
+				setState(endpoint, info.State.String())
+			}
+		}
+	}
+	t.OnConnAllow = func(info trace.DriverConnAllowStartInfo) func(trace.DriverConnAllowDoneInfo) {
+		if config.Details()&trace.DriverConnEvents != 0 {
+			allows.With(map[string]string{
+				"endpoint": info.Endpoint.Address(),
+				"node_id":  idToString(info.Endpoint.NodeID()),
+			}).Inc()
+		}
+		endpoint := info.Endpoint.Address()
+
+		return func(info trace.DriverConnAllowDoneInfo) {
+			if config.Details()&trace.DriverConnEvents != 0 && info.State != nil {
+				setState(endpoint, info.State.String())
+			}
+		}
+	}
+	t.OnConnClose = func(info trace.DriverConnCloseStartInfo) func(trace.DriverConnCloseDoneInfo) {
+		endpoint := info.Endpoint.Address()
+
+		return func(trace.DriverConnCloseDoneInfo) {
+			if config.Details()&trace.DriverConnEvents != 0 {
+				statesMu.Lock()
+				defer statesMu.Unlock()
+				if prev, has := knownStates[endpoint]; has {
+					delete(knownStates, endpoint)
+					states.With(map[string]string{
+						"state": prev,
+					}).Add(-1)
+				}
+			}
+		}
+	}
+
+	return t
+}
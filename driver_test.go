@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+
+	"github.com/ydb-platform/ydb-go-sdk-prometheus/v2/ydbpromtest"
+)
+
+type testEndpoint struct {
+	address  string
+	nodeID   uint32
+	location string
+	localDC  bool
+}
+
+func (e testEndpoint) String() string         { return e.address }
+func (e testEndpoint) NodeID() uint32         { return e.nodeID }
+func (e testEndpoint) Address() string        { return e.address }
+func (e testEndpoint) Location() string       { return e.location }
+func (e testEndpoint) LoadFactor() float32    { return 0 }
+func (e testEndpoint) LastUpdated() time.Time { return time.Time{} }
+func (e testEndpoint) LocalDC() bool          { return e.localDC }
+
+type testConnState string
+
+func (s testConnState) String() string { return string(s) }
+func (s testConnState) IsValid() bool  { return true }
+func (s testConnState) Code() int      { return 0 }
+
+func assertValue(t *testing.T, registry prometheus.Gatherer, name string, labels map[string]string, expected float64) {
+	t.Helper()
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+		var (
+			value float64
+			err   error
+		)
+		switch {
+		case f.GetMetric()[0].GetCounter() != nil:
+			value, err = ydbpromtest.CounterValue(registry, name, labels)
+		case f.GetMetric()[0].GetGauge() != nil:
+			value, err = ydbpromtest.GaugeValue(registry, name, labels)
+		default:
+			var count uint64
+			count, err = ydbpromtest.HistogramCount(registry, name, labels)
+			value = float64(count)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != expected {
+			t.Errorf("unexpected value of %s%v: %v, expected %v", name, labels, value, expected)
+		}
+
+		return
+	}
+	t.Errorf("metric %q not found", name)
+}
+
+func TestDriver(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	d := driver(Config(registry).WithSystem("ydb"))
+	e1 := testEndpoint{address: "node-1:2135", nodeID: 1}
+	e2 := testEndpoint{address: "node-2:2135", nodeID: 2}
+
+	d.OnConnDial(trace.DriverConnDialStartInfo{Endpoint: e1})(trace.DriverConnDialDoneInfo{})
+	d.OnConnDial(trace.DriverConnDialStartInfo{Endpoint: e2})(trace.DriverConnDialDoneInfo{Error: errors.New("test")})
+	d.OnConnStateChange(trace.DriverConnStateChangeStartInfo{Endpoint: e1})(
+		trace.DriverConnStateChangeDoneInfo{State: testConnState("online")},
+	)
+	d.OnConnStateChange(trace.DriverConnStateChangeStartInfo{Endpoint: e2})(
+		trace.DriverConnStateChangeDoneInfo{State: testConnState("online")},
+	)
+	d.OnConnBan(trace.DriverConnBanStartInfo{Endpoint: e2, Cause: errors.New("test")})(
+		trace.DriverConnBanDoneInfo{State: testConnState("banned")},
+	)
+
+	assertValue(t, registry, "ydb_go_sdk_ydb_driver_conn_dial_latency", map[string]string{"status": "OK"}, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_driver_conn_dial_latency", map[string]string{"status": "unknown"}, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_driver_conn_states", map[string]string{"state": "online"}, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_driver_conn_states", map[string]string{"state": "banned"}, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_driver_conn_bans", map[string]string{
+		"endpoint": "node-2:2135",
+		"node_id":  "2",
+		"cause":    "unknown",
+	}, 1)
+
+	d.OnConnAllow(trace.DriverConnAllowStartInfo{Endpoint: e2})(
+		trace.DriverConnAllowDoneInfo{State: testConnState("online")},
+	)
+	d.OnConnClose(trace.DriverConnCloseStartInfo{Endpoint: e1})(trace.DriverConnCloseDoneInfo{})
+
+	assertValue(t, registry, "ydb_go_sdk_ydb_driver_conn_allows", map[string]string{"endpoint": "node-2:2135"}, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_driver_conn_states", map[string]string{"state": "online"}, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_driver_conn_states", map[string]string{"state": "banned"}, 0)
+}
+
+func TestWithTraces(t *testing.T) {
+	if WithTraces(prometheus.NewRegistry()) == nil {
+		t.Fatal("nil option")
+	}
+}
+
+func TestConfigTraces(t *testing.T) {
+	if Config(prometheus.NewRegistry(), WithGrpcStats()).Traces() == nil {
+		t.Fatal("nil option")
+	}
+}
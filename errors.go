@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+)
+
+// errorBrief returns short description of error with bounded set of values for using as label value
+func errorBrief(err error) string {
+	if err == nil {
+		return "OK"
+	}
+	if errors.Is(err, io.EOF) {
+		return "io/EOF"
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "context/DeadlineExceeded"
+	}
+	if errors.Is(err, context.Canceled) {
+		return "context/Canceled"
+	}
+	if ydb.IsTransportError(err) {
+		return ydb.TransportError(err).Name()
+	}
+	if ydb.IsOperationErrorTransactionLocksInvalidated(err) {
+		return "operation/ABORTED/TLI"
+	}
+	if ydb.IsOperationError(err) {
+		return ydb.OperationError(err).Name()
+	}
+	if netErr := (*net.OpError)(nil); errors.As(err, &netErr) {
+		if netErr.Op != "" {
+			return "network/" + netErr.Op
+		}
+
+		return "network"
+	}
+
+	return "unknown"
+}
+
+func idToString(id uint32) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// expvars are names published by WithExpvar. expvar.Publish panics on repeated name, so
+// each name is published once and points to the latest config with this name
+var expvars = struct {
+	sync.Mutex
+	configs map[string]*atomic.Pointer[config]
+}{
+	configs: make(map[string]*atomic.Pointer[config]),
+}
+
+// Vars returns flat view of adapter metrics with same naming as prometheus exposition:
+// key is series name with labels (name{label="value",...}), histograms and timers
+// presented by _bucket, _sum and _count series
+func (c *config) Vars() map[string]float64 {
+	vars := make(map[string]float64)
+	for _, m := range c.Snapshot().Metrics {
+		for _, s := range m.Series {
+			switch {
+			case s.Value != nil:
+				vars[m.Name+seriesLabels(s.Labels)] = *s.Value
+			case s.Count != nil:
+				for _, b := range s.Buckets {
+					vars[m.Name+"_bucket"+seriesLabels(s.Labels, "le", formatFloat(b.UpperBound))] = float64(b.Count)
+				}
+				vars[m.Name+"_bucket"+seriesLabels(s.Labels, "le", "+Inf")] = float64(*s.Count)
+				vars[m.Name+"_sum"+seriesLabels(s.Labels)] = *s.Sum
+				vars[m.Name+"_count"+seriesLabels(s.Labels)] = float64(*s.Count)
+			}
+		}
+	}
+
+	return vars
+}
+
+// JSONHandler returns http.Handler which writes Vars as JSON object
+func (c *config) JSONHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(c.jsonVars())
+	})
+}
+
+// WithExpvar publishes adapter metrics to expvar (/debug/vars) with given name.
+// Name is published once: configs created later with same name replace published metrics
+// with their own. Name already published not by adapter is left untouched
+func WithExpvar(name string) option {
+	return func(c *config) {
+		c.expvarName = name
+	}
+}
+
+func (c *config) publishExpvar() {
+	expvars.Lock()
+	defer expvars.Unlock()
+
+	if published, has := expvars.configs[c.expvarName]; has {
+		published.Store(c)
+
+		return
+	}
+	if expvar.Get(c.expvarName) != nil {
+		return
+	}
+	published := &atomic.Pointer[config]{}
+	published.Store(c)
+	expvars.configs[c.expvarName] = published
+	expvar.Publish(c.expvarName, expvar.Func(func() any {
+		return published.Load().jsonVars()
+	}))
+}
+
+// jsonFloat is a value of Vars encoded to JSON. JSON has no NaN and infinities, so
+// they are encoded as strings "NaN", "+Inf" and "-Inf" like in prometheus exposition
+type jsonFloat float64
+
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	switch v := float64(f); {
+	case math.IsNaN(v):
+		return []byte(`"NaN"`), nil
+	case math.IsInf(v, 1):
+		return []byte(`"+Inf"`), nil
+	case math.IsInf(v, -1):
+		return []byte(`"-Inf"`), nil
+	default:
+		return json.Marshal(v)
+	}
+}
+
+func (c *config) jsonVars() map[string]jsonFloat {
+	vars := c.Vars()
+	encoded := make(map[string]jsonFloat, len(vars))
+	for k, v := range vars {
+		encoded[k] = jsonFloat(v)
+	}
+
+	return encoded
+}
+
+func seriesLabels(labels map[string]string, extra ...string) string {
+	pairs := make([]string, 0, len(labels)+len(extra)/2)
+	for k, v := range labels {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", k, v))
+	}
+	sort.Strings(pairs)
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", extra[i], extra[i+1]))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
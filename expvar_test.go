@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"math"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestVars(t *testing.T) {
+	c := Config(prometheus.NewRegistry(), WithTimerBuckets([]float64{0.5, 1}), WithExpvar("ydb_test"))
+	ydb := c.WithSystem("ydb")
+	ydb.CounterVec("errors", "status", "final").With(map[string]string{"status": "OK", "final": "true"}).Inc()
+	ydb.TimerVec("latency").With(nil).Record(750 * time.Millisecond)
+
+	expected := map[string]float64{
+		`ydb_go_sdk_ydb_errors{final="true",status="OK"}`: 1,
+		`ydb_go_sdk_ydb_latency_bucket{le="0.5"}`:         0,
+		`ydb_go_sdk_ydb_latency_bucket{le="1"}`:           1,
+		`ydb_go_sdk_ydb_latency_bucket{le="+Inf"}`:        1,
+		`ydb_go_sdk_ydb_latency_sum`:                      0.75,
+		`ydb_go_sdk_ydb_latency_count`:                    1,
+	}
+	if vars := c.Vars(); !reflect.DeepEqual(vars, expected) {
+		t.Fatalf("unexpected vars: %v", vars)
+	}
+
+	var published map[string]float64
+	if err := json.Unmarshal([]byte(expvar.Get("ydb_test").String()), &published); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(published, expected) {
+		t.Fatalf("unexpected published vars: %v", published)
+	}
+
+	w := httptest.NewRecorder()
+	c.JSONHandler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics.json", nil))
+	var handled map[string]float64
+	if err := json.Unmarshal(w.Body.Bytes(), &handled); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(handled, expected) {
+		t.Fatalf("unexpected handled vars: %v", handled)
+	}
+}
+
+func TestExpvarRepeatedName(t *testing.T) {
+	first := Config(prometheus.NewRegistry(), WithExpvar("ydb_test_repeated"))
+	first.WithSystem("ydb").GaugeVec("gauge").With(nil).Set(1)
+	second := Config(prometheus.NewRegistry(), WithExpvar("ydb_test_repeated"))
+	second.WithSystem("ydb").GaugeVec("gauge").With(nil).Set(2)
+
+	var published map[string]float64
+	if err := json.Unmarshal([]byte(expvar.Get("ydb_test_repeated").String()), &published); err != nil {
+		t.Fatal(err)
+	}
+	if expected := map[string]float64{"ydb_go_sdk_ydb_gauge": 2}; !reflect.DeepEqual(published, expected) {
+		t.Fatalf("unexpected published vars: %v", published)
+	}
+}
+
+func TestExpvarNonFinite(t *testing.T) {
+	c := Config(prometheus.NewRegistry(), WithExpvar("ydb_test_non_finite"))
+	gauge := c.WithSystem("ydb").GaugeVec("gauge", "value")
+	gauge.With(map[string]string{"value": "nan"}).Set(math.NaN())
+	gauge.With(map[string]string{"value": "inf"}).Set(math.Inf(1))
+	gauge.With(map[string]string{"value": "-inf"}).Set(math.Inf(-1))
+
+	expected := map[string]any{
+		`ydb_go_sdk_ydb_gauge{value="nan"}`:  "NaN",
+		`ydb_go_sdk_ydb_gauge{value="inf"}`:  "+Inf",
+		`ydb_go_sdk_ydb_gauge{value="-inf"}`: "-Inf",
+	}
+	var published map[string]any
+	if err := json.Unmarshal([]byte(expvar.Get("ydb_test_non_finite").String()), &published); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(published, expected) {
+		t.Fatalf("unexpected published vars: %v", published)
+	}
+
+	w := httptest.NewRecorder()
+	c.JSONHandler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics.json", nil))
+	var handled map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &handled); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(handled, expected) {
+		t.Fatalf("unexpected handled vars: %v", handled)
+	}
+}
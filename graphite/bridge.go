@@ -0,0 +1,227 @@
+// Package graphite pushes metrics of prometheus adapter for ydb-go-sdk to Graphite (Carbon)
+// using plaintext protocol
+package graphite
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	metrics "github.com/ydb-platform/ydb-go-sdk-prometheus/v2"
+)
+
+const (
+	defaultInterval = 15 * time.Second
+	defaultTimeout  = 15 * time.Second
+)
+
+// Snapshotter is a source of metrics. Adapter returned by metrics.Config implements it
+type Snapshotter interface {
+	Snapshot() metrics.Snapshot
+}
+
+// Config is a config of Bridge
+type Config struct {
+	// Address is a host:port of Carbon plaintext endpoint. Required
+	Address string
+
+	// Snapshotter is a source of metrics. Required
+	Snapshotter Snapshotter
+
+	// Prefix is prepended to all paths
+	Prefix string
+
+	// Interval between pushes. Default is 15 seconds
+	Interval time.Duration
+
+	// Timeout for connect and write to Carbon. Default is 15 seconds
+	Timeout time.Duration
+
+	// OnError called on each failed push in Run. Errors are ignored if nil
+	OnError func(err error)
+}
+
+// Bridge pushes metrics to Graphite
+type Bridge struct {
+	address     string
+	snapshotter Snapshotter
+	prefix      string
+	interval    time.Duration
+	timeout     time.Duration
+	onError     func(err error)
+	now         func() time.Time
+}
+
+// NewBridge returns Bridge by config
+func NewBridge(c *Config) (*Bridge, error) {
+	if c.Address == "" {
+		return nil, errors.New("graphite: empty address")
+	}
+	if c.Snapshotter == nil {
+		return nil, errors.New("graphite: nil snapshotter")
+	}
+	b := &Bridge{
+		address:     c.Address,
+		snapshotter: c.Snapshotter,
+		prefix:      c.Prefix,
+		interval:    c.Interval,
+		timeout:     c.Timeout,
+		onError:     c.OnError,
+		now:         time.Now,
+	}
+	if b.interval <= 0 {
+		b.interval = defaultInterval
+	}
+	if b.timeout <= 0 {
+		b.timeout = defaultTimeout
+	}
+
+	return b, nil
+}
+
+// Run pushes metrics every interval until ctx done
+func (b *Bridge) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.Push(); err != nil && b.onError != nil {
+				b.onError(err)
+			}
+		}
+	}
+}
+
+// Push pushes current metrics once
+func (b *Bridge) Push() error {
+	conn, err := net.DialTimeout("tcp", b.address, b.timeout)
+	if err != nil {
+		return fmt.Errorf("graphite: dial to %q failed: %w", b.address, err)
+	}
+	defer conn.Close()
+
+	if err = conn.SetWriteDeadline(time.Now().Add(b.timeout)); err != nil {
+		return fmt.Errorf("graphite: set deadline failed: %w", err)
+	}
+
+	w := bufio.NewWriter(conn)
+	if err = b.write(w, b.snapshotter.Snapshot()); err != nil {
+		return fmt.Errorf("graphite: write failed: %w", err)
+	}
+	if err = w.Flush(); err != nil {
+		return fmt.Errorf("graphite: write failed: %w", err)
+	}
+
+	return nil
+}
+
+func (b *Bridge) write(w io.Writer, s metrics.Snapshot) error {
+	ts := strconv.FormatInt(b.now().Unix(), 10)
+	for _, m := range s.Metrics {
+		path := b.path(m)
+		for _, series := range m.Series {
+			tags := tags(series.Labels)
+			switch {
+			case series.Value != nil:
+				if err := writeLine(w, path, tags, *series.Value, ts); err != nil {
+					return err
+				}
+			case series.Count != nil:
+				for _, bucket := range series.Buckets {
+					le := tags + ";le=" + strconv.FormatFloat(bucket.UpperBound, 'g', -1, 64)
+					if err := writeLine(w, path+".bucket", le, float64(bucket.Count), ts); err != nil {
+						return err
+					}
+				}
+				if err := writeLine(w, path+".bucket", tags+";le=+Inf", float64(*series.Count), ts); err != nil {
+					return err
+				}
+				if err := writeLine(w, path+".sum", tags, *series.Sum, ts); err != nil {
+					return err
+				}
+				if err := writeLine(w, path+".count", tags, float64(*series.Count), ts); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (b *Bridge) path(m metrics.MetricSnapshot) string {
+	parts := make([]string, 0, len(m.Path)+1)
+	if b.prefix != "" {
+		parts = append(parts, b.prefix)
+	}
+	for _, p := range m.Path {
+		parts = append(parts, sanitize(p))
+	}
+	if len(m.Path) == 0 {
+		parts = append(parts, sanitize(m.Name))
+	}
+
+	return strings.Join(parts, ".")
+}
+
+func writeLine(w io.Writer, path, tags string, value float64, ts string) error {
+	_, err := fmt.Fprintf(w, "%s%s %s %s\n", path, tags, strconv.FormatFloat(value, 'g', -1, 64), ts)
+
+	return err
+}
+
+// tags returns labels in graphite tags format (;tag1=value1;tag2=value2)
+func tags(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteByte(';')
+		b.WriteString(sanitize(k))
+		b.WriteByte('=')
+		b.WriteString(sanitizeTagValue(labels[k]))
+	}
+
+	return b.String()
+}
+
+// sanitize replaces symbols which not allowed in graphite path node
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
+
+// sanitizeTagValue replaces symbols which not allowed in graphite tag value
+func sanitizeTagValue(s string) string {
+	if s == "" {
+		return "_"
+	}
+
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ';', '~', ' ', '\t', '\n':
+			return '_'
+		default:
+			return r
+		}
+	}, s)
+}
@@ -0,0 +1,71 @@
+package graphite
+
+import (
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	metrics "github.com/ydb-platform/ydb-go-sdk-prometheus/v2"
+)
+
+func TestBridgePush(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			received <- err.Error()
+
+			return
+		}
+		defer conn.Close()
+		b, _ := io.ReadAll(conn)
+		received <- string(b)
+	}()
+
+	config := metrics.Config(prometheus.NewRegistry(), metrics.WithTimerBuckets([]float64{1}))
+	ydb := config.WithSystem("ydb")
+	ydb.WithSystem("retry").CounterVec("errors", "status").With(map[string]string{"status": "operation/ABORTED"}).Inc()
+	ydb.GaugeVec("conns").With(nil).Set(3)
+	ydb.TimerVec("latency").With(nil).Record(500 * time.Millisecond)
+
+	b, err := NewBridge(&Config{
+		Address:     l.Addr().String(),
+		Snapshotter: config,
+		Prefix:      "app",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.now = func() time.Time {
+		return time.Unix(1700000000, 0)
+	}
+	if err = b.Push(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Join([]string{
+		"app.ydb_go_sdk.ydb.conns 3 1700000000",
+		"app.ydb_go_sdk.ydb.latency.bucket;le=1 1 1700000000",
+		"app.ydb_go_sdk.ydb.latency.bucket;le=+Inf 1 1700000000",
+		"app.ydb_go_sdk.ydb.latency.sum 0.5 1700000000",
+		"app.ydb_go_sdk.ydb.latency.count 1 1700000000",
+		"app.ydb_go_sdk.ydb.retry.errors;status=operation/ABORTED 1 1700000000",
+	}, "\n") + "\n"
+	select {
+	case got := <-received:
+		if got != expected {
+			t.Fatalf("unexpected lines:\n%s\nexpected:\n%s", got, expected)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	ydbConfig "github.com/ydb-platform/ydb-go-sdk/v3/config"
+	"github.com/ydb-platform/ydb-go-sdk/v3/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	defaultBytesBuckets = prometheus.ExponentialBuckets(64, 4, 11)
+)
+
+var (
+	_ stats.Handler = (*grpcStatsHandler)(nil)
+)
+
+// WithGrpcStats injects gRPC stats handler into driver, which records message sizes,
+// stream messages counts and latency of RPC by full method name and status code
+func WithGrpcStats() option {
+	return func(c *config) {
+		c.grpcStats = true
+	}
+}
+
+// withGrpcStats returns driver option with gRPC stats handler
+func withGrpcStats(config metrics.Config) ydb.Option {
+	return ydb.With(ydbConfig.WithGrpcOptions(grpc.WithStatsHandler(newGrpcStatsHandler(config))))
+}
+
+type grpcStatsHandler struct {
+	requestBytes   metrics.HistogramVec
+	responseBytes  metrics.HistogramVec
+	streamMessages metrics.CounterVec
+	latency        metrics.TimerVec
+}
+
+func newGrpcStatsHandler(config metrics.Config) *grpcStatsHandler {
+	config = config.WithSystem("grpc")
+
+	return &grpcStatsHandler{
+		requestBytes:   config.HistogramVec("request_bytes", defaultBytesBuckets, "method"),
+		responseBytes:  config.HistogramVec("response_bytes", defaultBytesBuckets, "method"),
+		streamMessages: config.CounterVec("stream_messages", "method", "direction"),
+		latency:        config.TimerVec("latency", "method", "code"),
+	}
+}
+
+type grpcRPCKey struct{}
+
+type grpcRPC struct {
+	method    string
+	streaming bool
+}
+
+func (h *grpcStatsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, grpcRPCKey{}, &grpcRPC{method: info.FullMethodName})
+}
+
+func (h *grpcStatsHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	rpc, ok := ctx.Value(grpcRPCKey{}).(*grpcRPC)
+	if !ok {
+		return
+	}
+	switch s := s.(type) {
+	case *stats.Begin:
+		rpc.streaming = s.IsClientStream || s.IsServerStream
+	case *stats.OutPayload:
+		h.requestBytes.With(map[string]string{
+			"method": rpc.method,
+		}).Record(float64(s.WireLength))
+		if rpc.streaming {
+			h.streamMessages.With(map[string]string{
+				"method":    rpc.method,
+				"direction": "sent",
+			}).Inc()
+		}
+	case *stats.InPayload:
+		h.responseBytes.With(map[string]string{
+			"method": rpc.method,
+		}).Record(float64(s.WireLength))
+		if rpc.streaming {
+			h.streamMessages.With(map[string]string{
+				"method":    rpc.method,
+				"direction": "received",
+			}).Inc()
+		}
+	case *stats.End:
+		h.latency.With(map[string]string{
+			"method": rpc.method,
+			"code":   status.Code(s.Error).String(),
+		}).Record(s.EndTime.Sub(s.BeginTime))
+	}
+}
+
+func (h *grpcStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (h *grpcStatsHandler) HandleConn(context.Context, stats.ConnStats) {}
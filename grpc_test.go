@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+)
+
+func TestGrpcStatsHandler(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	h := newGrpcStatsHandler(Config(registry).WithSystem("ydb"))
+
+	const (
+		unary  = "/Ydb.Table.V1.TableService/BulkUpsert"
+		stream = "/Ydb.Table.V1.TableService/StreamExecuteScanQuery"
+	)
+	begin := time.Now()
+
+	ctx := h.TagRPC(context.Background(), &stats.RPCTagInfo{FullMethodName: unary})
+	h.HandleRPC(ctx, &stats.Begin{BeginTime: begin})
+	h.HandleRPC(ctx, &stats.OutPayload{WireLength: 1 << 20})
+	h.HandleRPC(ctx, &stats.InPayload{WireLength: 100})
+	h.HandleRPC(ctx, &stats.End{BeginTime: begin, EndTime: begin.Add(time.Second)})
+
+	ctx = h.TagRPC(context.Background(), &stats.RPCTagInfo{FullMethodName: stream})
+	h.HandleRPC(ctx, &stats.Begin{BeginTime: begin, IsServerStream: true})
+	h.HandleRPC(ctx, &stats.OutPayload{WireLength: 100})
+	for i := 0; i < 3; i++ {
+		h.HandleRPC(ctx, &stats.InPayload{WireLength: 1 << 10})
+	}
+	h.HandleRPC(ctx, &stats.End{
+		BeginTime: begin,
+		EndTime:   begin.Add(time.Second),
+		Error:     status.Error(codes.Unavailable, "test"),
+	})
+	h.HandleRPC(context.Background(), &stats.End{Error: errors.New("untagged")})
+
+	assertValue(t, registry, "ydb_go_sdk_ydb_grpc_request_bytes", map[string]string{"method": unary}, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_grpc_response_bytes", map[string]string{"method": stream}, 3)
+	assertValue(t, registry, "ydb_go_sdk_ydb_grpc_stream_messages", map[string]string{"method": unary}, 0)
+	assertValue(t, registry, "ydb_go_sdk_ydb_grpc_stream_messages", map[string]string{
+		"method":    stream,
+		"direction": "received",
+	}, 3)
+	assertValue(t, registry, "ydb_go_sdk_ydb_grpc_stream_messages", map[string]string{
+		"method":    stream,
+		"direction": "sent",
+	}, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_grpc_latency", map[string]string{"method": unary, "code": "OK"}, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_grpc_latency", map[string]string{"method": stream, "code": "Unavailable"}, 1)
+}
+
+func TestWithGrpcStats(t *testing.T) {
+	if WithTraces(prometheus.NewRegistry(), WithGrpcStats()) == nil {
+		t.Fatal("nil option")
+	}
+}
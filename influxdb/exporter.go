@@ -0,0 +1,314 @@
+// Package influxdb exports metrics of prometheus adapter for ydb-go-sdk to InfluxDB
+// using line protocol over HTTP (v2 write API) or UDP
+package influxdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	metrics "github.com/ydb-platform/ydb-go-sdk-prometheus/v2"
+	"github.com/ydb-platform/ydb-go-sdk-prometheus/v2/internal/retry"
+)
+
+const (
+	defaultInterval  = 15 * time.Second
+	defaultTimeout   = 15 * time.Second
+	defaultBatchSize = 5000
+	// defaultMaxDatagramSize fits datagram into ethernet MTU 1500 with IP and UDP headers
+	defaultMaxDatagramSize = 1400
+	defaultMaxRetries      = 3
+	defaultRetryBackoff    = time.Second
+)
+
+// Snapshotter is a source of metrics. Adapter returned by metrics.Config implements it
+type Snapshotter interface {
+	Snapshot() metrics.Snapshot
+}
+
+// Config is a config of Exporter
+type Config struct {
+	// URL of InfluxDB. Scheme http or https means v2 write API (http://localhost:8086),
+	// scheme udp means UDP listener (udp://localhost:8089). Required
+	URL string
+
+	// Org, Bucket and Token are parameters of v2 write API
+	Org    string
+	Bucket string
+	Token  string
+
+	// Snapshotter is a source of metrics. Required
+	Snapshotter Snapshotter
+
+	// Interval between exports. Default is 15 seconds
+	Interval time.Duration
+
+	// Timeout of single write request. Default is 15 seconds
+	Timeout time.Duration
+
+	// BatchSize is a max count of lines in single write. Default is 5000
+	BatchSize int
+
+	// MaxDatagramSize is a max size of UDP datagram in bytes. Batches of UDP writes are split by
+	// this size in addition to BatchSize, line longer than MaxDatagramSize is sent in own datagram.
+	// Default is 1400 bytes
+	MaxDatagramSize int
+
+	// MaxRetries is a max count of retries of failed write. Default is 3, negative value disables retries
+	MaxRetries int
+
+	// RetryBackoff is a delay before first retry, each next delay doubled. Default is 1 second
+	RetryBackoff time.Duration
+
+	// HTTPClient for v2 write API. Default is http.DefaultClient
+	HTTPClient *http.Client
+
+	// OnError called on each failed export in Run. Errors are ignored if nil
+	OnError func(err error)
+}
+
+// Exporter writes metrics to InfluxDB
+type Exporter struct {
+	config Config
+	target *url.URL
+	now    func() time.Time
+}
+
+// NewExporter returns Exporter by config
+func NewExporter(c *Config) (*Exporter, error) {
+	if c.Snapshotter == nil {
+		return nil, errors.New("influxdb: nil snapshotter")
+	}
+	target, err := url.Parse(c.URL)
+	if err != nil {
+		return nil, fmt.Errorf("influxdb: wrong url %q: %w", c.URL, err)
+	}
+	switch target.Scheme {
+	case "http", "https":
+		target = target.JoinPath("api", "v2", "write")
+		q := target.Query()
+		q.Set("org", c.Org)
+		q.Set("bucket", c.Bucket)
+		q.Set("precision", "ns")
+		target.RawQuery = q.Encode()
+	case "udp":
+	default:
+		return nil, fmt.Errorf("influxdb: unsupported scheme of url %q", c.URL)
+	}
+	e := &Exporter{
+		config: *c,
+		target: target,
+		now:    time.Now,
+	}
+	if e.config.Interval <= 0 {
+		e.config.Interval = defaultInterval
+	}
+	if e.config.Timeout <= 0 {
+		e.config.Timeout = defaultTimeout
+	}
+	if e.config.BatchSize <= 0 {
+		e.config.BatchSize = defaultBatchSize
+	}
+	if e.config.MaxDatagramSize <= 0 {
+		e.config.MaxDatagramSize = defaultMaxDatagramSize
+	}
+	if e.config.MaxRetries == 0 {
+		e.config.MaxRetries = defaultMaxRetries
+	}
+	if e.config.RetryBackoff <= 0 {
+		e.config.RetryBackoff = defaultRetryBackoff
+	}
+	if e.config.HTTPClient == nil {
+		e.config.HTTPClient = http.DefaultClient
+	}
+
+	return e, nil
+}
+
+// Run exports metrics every interval until ctx done
+func (e *Exporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := e.Export(ctx); err != nil && e.config.OnError != nil {
+				e.config.OnError(err)
+			}
+		}
+	}
+}
+
+// Export writes current metrics once
+func (e *Exporter) Export(ctx context.Context) error {
+	lines := Lines(e.config.Snapshotter.Snapshot(), e.now())
+	for len(lines) > 0 {
+		n := e.batchLen(lines)
+		batch := []byte(strings.Join(lines[:n], ""))
+		err := retry.Do(ctx, e.config.MaxRetries, e.config.RetryBackoff, func(ctx context.Context) error {
+			return e.write(ctx, batch)
+		})
+		if err != nil {
+			return err
+		}
+		lines = lines[n:]
+	}
+
+	return nil
+}
+
+// batchLen returns count of first lines which fit into single write. Batch always has at least one line
+func (e *Exporter) batchLen(lines []string) int {
+	n := e.config.BatchSize
+	if n > len(lines) {
+		n = len(lines)
+	}
+	if e.target.Scheme != "udp" {
+		return n
+	}
+	size := len(lines[0])
+	for i := 1; i < n; i++ {
+		size += len(lines[i])
+		if size > e.config.MaxDatagramSize {
+			return i
+		}
+	}
+
+	return n
+}
+
+func (e *Exporter) write(ctx context.Context, batch []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, e.config.Timeout)
+	defer cancel()
+
+	if e.target.Scheme == "udp" {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "udp", e.target.Host)
+		if err != nil {
+			return fmt.Errorf("influxdb: dial to %q failed: %w", e.target.Host, err)
+		}
+		defer conn.Close()
+		if _, err = conn.Write(batch); err != nil {
+			return fmt.Errorf("influxdb: write failed: %w", err)
+		}
+
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.target.String(), bytes.NewReader(batch))
+	if err != nil {
+		return fmt.Errorf("influxdb: create request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if e.config.Token != "" {
+		req.Header.Set("Authorization", "Token "+e.config.Token)
+	}
+	resp, err := e.config.HTTPClient.Do(req)
+	if err != nil {
+		return retry.Retryable(fmt.Errorf("influxdb: write failed: %w", err))
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	err = fmt.Errorf("influxdb: write failed with status %q: %s", resp.Status, body)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return retry.Retryable(err)
+	}
+
+	return err
+}
+
+// Lines returns snapshot in line protocol: measurement is a metric name, labels are tags.
+// Counters and gauges have single field value, timers and histograms have fields
+// count, sum and cumulative buckets counts (le_<upper bound>). Line protocol has no NaN and
+// infinities, so fields with such values are skipped
+func Lines(s metrics.Snapshot, ts time.Time) []string {
+	var (
+		lines     []string
+		timestamp = strconv.FormatInt(ts.UnixNano(), 10)
+	)
+	for _, m := range s.Metrics {
+		measurement := escape(m.Name, ", ")
+		for _, series := range m.Series {
+			var fields []string
+			switch {
+			case series.Value != nil:
+				if !finite(*series.Value) {
+					continue
+				}
+				fields = append(fields, "value="+formatFloat(*series.Value))
+			case series.Count != nil:
+				fields = append(fields, "count="+strconv.FormatUint(*series.Count, 10)+"i")
+				if finite(*series.Sum) {
+					fields = append(fields, "sum="+formatFloat(*series.Sum))
+				}
+				for _, b := range series.Buckets {
+					fields = append(fields, "le_"+formatFloat(b.UpperBound)+"="+strconv.FormatUint(b.Count, 10)+"i")
+				}
+				fields = append(fields, "le_+Inf="+strconv.FormatUint(*series.Count, 10)+"i")
+			default:
+				continue
+			}
+			lines = append(lines, measurement+tags(series.Labels)+" "+strings.Join(fields, ",")+" "+timestamp+"\n")
+		}
+	}
+
+	return lines
+}
+
+func finite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+func tags(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		if labels[k] == "" {
+			continue
+		}
+		b.WriteByte(',')
+		b.WriteString(escape(k, ",= "))
+		b.WriteByte('=')
+		b.WriteString(escape(labels[k], ",= "))
+	}
+
+	return b.String()
+}
+
+func escape(s, chars string) string {
+	if !strings.ContainsAny(s, chars) {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(chars, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,223 @@
+package influxdb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	metrics "github.com/ydb-platform/ydb-go-sdk-prometheus/v2"
+)
+
+var expectedLines = []string{
+	"ydb_go_sdk_ydb_conns value=3 1700000000000000000\n",
+	"ydb_go_sdk_ydb_latency,endpoint=localhost:2136 count=1i,sum=0.5,le_1=1i,le_+Inf=1i 1700000000000000000\n",
+	"ydb_go_sdk_ydb_retry_errors,label=with\\ space,status=operation/ABORTED value=1 1700000000000000000\n",
+}
+
+func newSnapshotter() Snapshotter {
+	config := metrics.Config(prometheus.NewRegistry(), metrics.WithTimerBuckets([]float64{1}))
+	ydb := config.WithSystem("ydb")
+	ydb.WithSystem("retry").CounterVec("errors", "status", "label").With(map[string]string{
+		"status": "operation/ABORTED",
+		"label":  "with space",
+	}).Inc()
+	ydb.GaugeVec("conns").With(nil).Set(3)
+	ydb.TimerVec("latency", "endpoint").With(map[string]string{"endpoint": "localhost:2136"}).Record(500 * time.Millisecond)
+
+	return config
+}
+
+func TestExportHTTP(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests int
+		bodies   []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		if r.URL.Path != "/api/v2/write" ||
+			r.URL.Query().Get("org") != "org" ||
+			r.URL.Query().Get("bucket") != "bucket" ||
+			r.URL.Query().Get("precision") != "ns" ||
+			r.Header.Get("Authorization") != "Token secret" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	e, err := NewExporter(&Config{
+		URL:          server.URL,
+		Org:          "org",
+		Bucket:       "bucket",
+		Token:        "secret",
+		Snapshotter:  newSnapshotter(),
+		BatchSize:    2,
+		RetryBackoff: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.now = func() time.Time {
+		return time.Unix(1700000000, 0)
+	}
+	if err = e.Export(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != 3 {
+		t.Fatalf("unexpected requests count: %d", requests)
+	}
+	expected := []string{
+		strings.Join(expectedLines[:2], ""),
+		strings.Join(expectedLines[2:], ""),
+	}
+	if len(bodies) != len(expected) || bodies[0] != expected[0] || bodies[1] != expected[1] {
+		t.Fatalf("unexpected bodies:\n%q\nexpected:\n%q", bodies, expected)
+	}
+}
+
+func TestExportHTTPNotRetryable(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	e, err := NewExporter(&Config{
+		URL:          server.URL,
+		Snapshotter:  newSnapshotter(),
+		RetryBackoff: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = e.Export(context.Background()); err == nil {
+		t.Fatal("no error")
+	}
+	if requests != 1 {
+		t.Fatalf("unexpected requests count: %d", requests)
+	}
+}
+
+func TestExportUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	e, err := NewExporter(&Config{
+		URL:         "udp://" + conn.LocalAddr().String(),
+		Snapshotter: newSnapshotter(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.now = func() time.Time {
+		return time.Unix(1700000000, 0)
+	}
+	if err = e.Export(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 64*1024)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := string(buf[:n]), strings.Join(expectedLines, ""); got != expected {
+		t.Fatalf("unexpected datagram:\n%s\nexpected:\n%s", got, expected)
+	}
+}
+
+func TestExportUDPMaxDatagramSize(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	config := metrics.Config(prometheus.NewRegistry())
+	errs := config.WithSystem("ydb").CounterVec("errors", "status")
+	for i := 0; i < 100; i++ {
+		errs.With(map[string]string{"status": fmt.Sprintf("status-%03d", i)}).Inc()
+	}
+	const maxDatagramSize = 512
+	e, err := NewExporter(&Config{
+		URL:             "udp://" + conn.LocalAddr().String(),
+		Snapshotter:     config,
+		MaxDatagramSize: maxDatagramSize,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := Lines(config.Snapshot(), e.now())
+	if size := len(strings.Join(lines, "")); size <= maxDatagramSize {
+		t.Fatalf("snapshot fits into single datagram: %d bytes", size)
+	}
+	if err = e.Export(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 64*1024)
+	received := 0
+	for received < len(lines) {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n > maxDatagramSize {
+			t.Fatalf("datagram of %d bytes exceeds max datagram size", n)
+		}
+		received += strings.Count(string(buf[:n]), "\n")
+	}
+	if received != len(lines) {
+		t.Fatalf("unexpected count of received lines: %d, expected %d", received, len(lines))
+	}
+}
+
+func TestLinesNonFinite(t *testing.T) {
+	config := metrics.Config(prometheus.NewRegistry(), metrics.WithTimerBuckets([]float64{1}))
+	ydb := config.WithSystem("ydb")
+	gauge := ydb.GaugeVec("gauge", "value")
+	gauge.With(map[string]string{"value": "nan"}).Set(math.NaN())
+	gauge.With(map[string]string{"value": "inf"}).Set(math.Inf(1))
+	gauge.With(map[string]string{"value": "one"}).Set(1)
+	ydb.HistogramVec("histogram", []float64{1}).With(nil).Record(math.Inf(-1))
+
+	expected := []string{
+		"ydb_go_sdk_ydb_gauge,value=one value=1 1700000000000000000\n",
+		"ydb_go_sdk_ydb_histogram count=1i,le_1=1i,le_+Inf=1i 1700000000000000000\n",
+	}
+	lines := Lines(config.Snapshot(), time.Unix(0, 1700000000000000000))
+	if fmt.Sprint(lines) != fmt.Sprint(expected) {
+		t.Fatalf("unexpected lines:\n%q\nwant:\n%q", lines, expected)
+	}
+}
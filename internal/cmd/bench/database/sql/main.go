@@ -81,6 +81,10 @@ func main() {
 	db.SetMaxIdleConns(threads * 3)
 	db.SetConnMaxIdleTime(time.Second)
 
+	if err := metrics.RegisterDBStats(registry, db); err != nil {
+		panic(err)
+	}
+
 	err = prepareSchema(ctx, db)
 	if err != nil {
 		panic("create tables error: " + err.Error())
@@ -0,0 +1,52 @@
+// Package retry contains retries of writes to remote storages, which are shared by push exporters
+package retry
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+// Retryable marks err as temporary: write failed with this error may succeed on next attempt
+func Retryable(err error) error {
+	return &retryableError{err: err}
+}
+
+// IsRetryable reports whether err or any error in its chain marked by Retryable
+func IsRetryable(err error) bool {
+	var retryable *retryableError
+
+	return errors.As(err, &retryable)
+}
+
+// Do calls f until it succeeds, returns not retryable error or maxRetries retries are made.
+// Delay before first retry is backoff, each next delay doubled
+func Do(ctx context.Context, maxRetries int, backoff time.Duration, f func(ctx context.Context) error) error {
+	for attempt := 0; ; attempt++ {
+		err := f(ctx)
+		if err == nil {
+			return nil
+		}
+		if attempt >= maxRetries || !IsRetryable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(backoff):
+			backoff *= 2
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/metrics"
+)
+
+// otherLabelValue replaces values of labels which exceed limit of distinct values
+const otherLabelValue = "other"
+
+// WithLabelValuesLimit limits number of distinct values of unbounded labels (for example,
+// topic partitions or rate limiter resources). Values over the limit are reported as "other".
+// Zero or negative limit disables limitation
+func WithLabelValuesLimit(limit int) option {
+	return func(c *config) {
+		c.labelLimit = limit
+	}
+}
+
+func (c *config) labelValuesLimit() int {
+	return c.labelLimit
+}
+
+func (c *statsdConfig) labelValuesLimit() int {
+	return c.labelLimit
+}
+
+// labelValuesLimit returns limit of distinct values of unbounded labels for config
+func labelValuesLimit(config metrics.Config) int {
+	if c, ok := config.(interface{ labelValuesLimit() int }); ok {
+		return c.labelValuesLimit()
+	}
+
+	return defaultLabelValuesLimit
+}
+
+// boundedLabel guards cardinality of label: first limit distinct keys keep their values,
+// values of all other keys are replaced with "other"
+type boundedLabel struct {
+	m     sync.Mutex
+	limit int
+	keys  map[string]struct{}
+}
+
+func newBoundedLabel(config metrics.Config) *boundedLabel {
+	return &boundedLabel{
+		limit: labelValuesLimit(config),
+		keys:  make(map[string]struct{}),
+	}
+}
+
+// value returns value of label for key. Key identifies series (for example, topic with partition),
+// so same value (partition number) of different keys (topics) counts separately
+func (l *boundedLabel) value(key, value string) string {
+	if l.limit <= 0 {
+		return value
+	}
+	l.m.Lock()
+	defer l.m.Unlock()
+	if _, has := l.keys[key]; has {
+		return value
+	}
+	if len(l.keys) >= l.limit {
+		return otherLabelValue
+	}
+	l.keys[key] = struct{}{}
+
+	return value
+}
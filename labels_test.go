@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestBoundedLabel(t *testing.T) {
+	l := newBoundedLabel(Config(prometheus.NewRegistry(), WithLabelValuesLimit(2)).WithSystem("ydb"))
+	for _, tt := range []struct {
+		key      string
+		value    string
+		expected string
+	}{
+		{key: "a/1", value: "1", expected: "1"},
+		{key: "b/1", value: "1", expected: "1"},
+		{key: "a/1", value: "1", expected: "1"},
+		{key: "a/2", value: "2", expected: otherLabelValue},
+		{key: "b/1", value: "1", expected: "1"},
+	} {
+		if got := l.value(tt.key, tt.value); got != tt.expected {
+			t.Errorf("unexpected value for key %q: %q, expected %q", tt.key, got, tt.expected)
+		}
+	}
+}
+
+func TestBoundedLabelUnlimited(t *testing.T) {
+	l := newBoundedLabel(Config(prometheus.NewRegistry(), WithLabelValuesLimit(0)))
+	for i := 0; i < defaultLabelValuesLimit*2; i++ {
+		value := strconv.Itoa(i)
+		if got := l.value(value, value); got != value {
+			t.Fatalf("unexpected value: %q, expected %q", got, value)
+		}
+	}
+}
+
+func TestLabelValuesLimit(t *testing.T) {
+	if limit := labelValuesLimit(Config(prometheus.NewRegistry())); limit != defaultLabelValuesLimit {
+		t.Errorf("unexpected default limit: %d", limit)
+	}
+	statsd, err := StatsdConfig(listenUDP(t).LocalAddr().String(), WithLabelValuesLimit(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer statsd.Close()
+	if limit := labelValuesLimit(statsd.WithSystem("ydb")); limit != 5 {
+		t.Errorf("unexpected statsd limit: %d", limit)
+	}
+}
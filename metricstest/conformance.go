@@ -0,0 +1,228 @@
+// Package metricstest contains conformance tests for implementations of ydb-go-sdk metrics.Config
+package metricstest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ydb-platform/ydb-go-sdk/v3/metrics"
+)
+
+// Run drives config created by newConfig through every method of metrics.Config interface.
+// newConfig called for each subtest, so each subtest works with clean registry
+func Run(t *testing.T, newConfig func(t *testing.T) metrics.Config) {
+	t.Helper()
+
+	run(t, func(t *testing.T) (metrics.Config, prometheus.Gatherer) {
+		return newConfig(t), nil
+	})
+}
+
+// RunGathered is like Run, but also checks values of metrics gathered from gatherer, which
+// returned by newConfig together with config
+func RunGathered(t *testing.T, newConfig func(t *testing.T) (metrics.Config, prometheus.Gatherer)) {
+	t.Helper()
+
+	run(t, newConfig)
+}
+
+func run(t *testing.T, newConfig func(t *testing.T) (metrics.Config, prometheus.Gatherer)) {
+	t.Helper()
+
+	t.Run("Details", func(t *testing.T) {
+		c, _ := newConfig(t)
+		_ = c.Details()
+	})
+	t.Run("WithSystem", func(t *testing.T) {
+		c, g := newConfig(t)
+		testWithSystem(t, c, g)
+	})
+	t.Run("CounterVec", func(t *testing.T) {
+		c, g := newConfig(t)
+		testCounterVec(t, c.WithSystem("test"), g)
+	})
+	t.Run("GaugeVec", func(t *testing.T) {
+		c, g := newConfig(t)
+		testGaugeVec(t, c.WithSystem("test"), g)
+	})
+	t.Run("TimerVec", func(t *testing.T) {
+		c, g := newConfig(t)
+		testTimerVec(t, c.WithSystem("test"), g)
+	})
+	t.Run("HistogramVec", func(t *testing.T) {
+		c, g := newConfig(t)
+		testHistogramVec(t, c.WithSystem("test"), g)
+	})
+	t.Run("RepeatedNames", func(t *testing.T) {
+		c, g := newConfig(t)
+		testRepeatedNames(t, c, g)
+	})
+	t.Run("Concurrent", func(t *testing.T) {
+		c, g := newConfig(t)
+		testConcurrent(t, c, g)
+	})
+	t.Run("WithTraces", func(t *testing.T) {
+		c, _ := newConfig(t)
+		if metrics.WithTraces(c) == nil {
+			t.Fatal("metrics.WithTraces returns nil option")
+		}
+	})
+}
+
+func testWithSystem(t *testing.T, c metrics.Config, g prometheus.Gatherer) {
+	a := c.WithSystem("a")
+	if a == nil {
+		t.Fatal("WithSystem returns nil")
+	}
+	b := a.WithSystem("b")
+	if b == nil {
+		t.Fatal("nested WithSystem returns nil")
+	}
+	if a.Details() != c.Details() || b.Details() != c.Details() {
+		t.Errorf("details of subsystem config differs from parent: %v, %v, %v",
+			c.Details(), a.Details(), b.Details(),
+		)
+	}
+	b.CounterVec("counter", "label").With(map[string]string{"label": "ab"}).Inc()
+	// same subsystems requested again must not conflict with already created vectors
+	c.WithSystem("a").WithSystem("b").CounterVec("counter", "label").With(map[string]string{"label": "ab"}).Inc()
+	// same name in other subsystem is another metric
+	a.CounterVec("counter", "label").With(map[string]string{"label": "a"}).Inc()
+
+	expectValue(t, g, "a_b_counter", map[string]string{"label": "ab"}, 2)
+	expectValue(t, g, "a_counter", map[string]string{"label": "a"}, 1)
+	expectValue(t, g, "a_counter", map[string]string{"label": "ab"}, 0)
+}
+
+func testCounterVec(t *testing.T, c metrics.Config, g prometheus.Gatherer) {
+	vec := c.CounterVec("counter", "a", "b")
+	if vec == nil {
+		t.Fatal("CounterVec returns nil")
+	}
+	cnt := vec.With(map[string]string{"a": "1", "b": "2"})
+	if cnt == nil {
+		t.Fatal("CounterVec.With returns nil")
+	}
+	cnt.Inc()
+	if c.CounterVec("counter", "a", "b") == nil {
+		t.Fatal("CounterVec returns nil for cached vector")
+	}
+	c.CounterVec("counter_without_labels").With(nil).Inc()
+	c.CounterVec("counter_without_labels").With(map[string]string{}).Inc()
+
+	expectValue(t, g, "counter", map[string]string{"a": "1", "b": "2"}, 1)
+	expectValue(t, g, "counter_without_labels", nil, 2)
+}
+
+func testGaugeVec(t *testing.T, c metrics.Config, g prometheus.Gatherer) {
+	vec := c.GaugeVec("gauge", "a")
+	if vec == nil {
+		t.Fatal("GaugeVec returns nil")
+	}
+	gauge := vec.With(map[string]string{"a": "1"})
+	if gauge == nil {
+		t.Fatal("GaugeVec.With returns nil")
+	}
+	gauge.Set(10)
+	gauge.Add(-3)
+	if c.GaugeVec("gauge", "a") == nil {
+		t.Fatal("GaugeVec returns nil for cached vector")
+	}
+	c.GaugeVec("gauge_without_labels").With(nil).Set(1)
+
+	expectValue(t, g, "gauge", map[string]string{"a": "1"}, 7)
+	expectValue(t, g, "gauge_without_labels", nil, 1)
+}
+
+func testTimerVec(t *testing.T, c metrics.Config, g prometheus.Gatherer) {
+	vec := c.TimerVec("timer", "a")
+	if vec == nil {
+		t.Fatal("TimerVec returns nil")
+	}
+	timer := vec.With(map[string]string{"a": "1"})
+	if timer == nil {
+		t.Fatal("TimerVec.With returns nil")
+	}
+	timer.Record(time.Millisecond)
+	timer.Record(0)
+	if c.TimerVec("timer", "a") == nil {
+		t.Fatal("TimerVec returns nil for cached vector")
+	}
+	c.TimerVec("timer_without_labels").With(nil).Record(time.Second)
+
+	expectHistogram(t, g, "timer", map[string]string{"a": "1"}, 2, time.Millisecond.Seconds(), nil)
+	expectHistogram(t, g, "timer_without_labels", nil, 1, time.Second.Seconds(), nil)
+}
+
+func testHistogramVec(t *testing.T, c metrics.Config, g prometheus.Gatherer) {
+	vec := c.HistogramVec("histogram", []float64{1, 2, 5}, "a")
+	if vec == nil {
+		t.Fatal("HistogramVec returns nil")
+	}
+	h := vec.With(map[string]string{"a": "1"})
+	if h == nil {
+		t.Fatal("HistogramVec.With returns nil")
+	}
+	h.Record(0)
+	h.Record(3)
+	h.Record(100)
+	if c.HistogramVec("histogram", []float64{1, 2, 5}, "a") == nil {
+		t.Fatal("HistogramVec returns nil for cached vector")
+	}
+	c.HistogramVec("histogram_without_labels", []float64{1}).With(nil).Record(1)
+
+	expectHistogram(t, g, "histogram", map[string]string{"a": "1"}, 3, 103, []float64{1, 2, 5})
+	expectHistogram(t, g, "histogram_without_labels", nil, 1, 1, []float64{1})
+}
+
+// testRepeatedNames checks that vectors requested again with same name and specification
+// are same metrics
+func testRepeatedNames(t *testing.T, c metrics.Config, g prometheus.Gatherer) {
+	c = c.WithSystem("test")
+	c.HistogramVec("histogram", []float64{1, 2}, "a").With(map[string]string{"a": "1"}).Record(1)
+	vec := c.HistogramVec("histogram", []float64{1, 2}, "a")
+	if vec == nil {
+		t.Fatal("HistogramVec returns nil for repeated name")
+	}
+	vec.With(map[string]string{"a": "1"}).Record(2)
+	c.TimerVec("timer", "a").With(map[string]string{"a": "1"}).Record(time.Second)
+	c.TimerVec("timer", "a").With(map[string]string{"a": "1"}).Record(time.Second)
+
+	expectHistogram(t, g, "histogram", map[string]string{"a": "1"}, 2, 3, []float64{1, 2})
+	expectHistogram(t, g, "timer", map[string]string{"a": "1"}, 2, 2, nil)
+}
+
+func testConcurrent(t *testing.T, c metrics.Config, g prometheus.Gatherer) {
+	const (
+		goroutines = 16
+		iterations = 100
+	)
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func(i int) {
+			defer wg.Done()
+			c := c.WithSystem("concurrent")
+			labels := map[string]string{"worker": fmt.Sprintf("%d", i%4)}
+			for j := 0; j < iterations; j++ {
+				c.CounterVec("counter", "worker").With(labels).Inc()
+				c.GaugeVec("gauge", "worker").With(labels).Add(1)
+				c.TimerVec("timer", "worker").With(labels).Record(time.Duration(j) * time.Millisecond)
+				c.HistogramVec("histogram", []float64{10, 50}, "worker").With(labels).Record(float64(j))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for worker := 0; worker < 4; worker++ {
+		labels := map[string]string{"worker": fmt.Sprintf("%d", worker)}
+		expectValue(t, g, "concurrent_counter", labels, goroutines/4*iterations)
+		expectValue(t, g, "concurrent_gauge", labels, goroutines/4*iterations)
+		expectHistogram(t, g, "concurrent_histogram", labels, goroutines/4*iterations,
+			goroutines/4*float64(iterations*(iterations-1)/2), []float64{10, 50},
+		)
+	}
+}
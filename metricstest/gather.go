@@ -0,0 +1,139 @@
+package metricstest
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// family returns gathered family with name. Name of family is matched by tail, so it matches
+// family with any namespace and any separator between subsystems (for example, name "a_counter"
+// matches families "ydb_a_counter" and "app__a__counter")
+func family(t *testing.T, g prometheus.Gatherer, name string) *dto.MetricFamily {
+	t.Helper()
+
+	families, err := g.Gather()
+	if err != nil {
+		t.Fatalf("gather failed: %v", err)
+	}
+	parts := strings.Split(name, "_")
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+	re := regexp.MustCompile("(^|_)" + strings.Join(parts, "_+") + "$")
+	var found *dto.MetricFamily
+	for _, f := range families {
+		if !re.MatchString(f.GetName()) {
+			continue
+		}
+		if found != nil {
+			t.Fatalf("name %q matches families %q and %q", name, found.GetName(), f.GetName())
+		}
+		found = f
+	}
+	if found == nil {
+		t.Fatalf("family %q not gathered", name)
+	}
+
+	return found
+}
+
+// series returns series of family which have all labels
+func series(f *dto.MetricFamily, labels map[string]string) (series []*dto.Metric) {
+	for _, m := range f.GetMetric() {
+		matched := 0
+		for _, l := range m.GetLabel() {
+			if v, has := labels[l.GetName()]; has && v == l.GetValue() {
+				matched++
+			}
+		}
+		if matched == len(labels) {
+			series = append(series, m)
+		}
+	}
+
+	return series
+}
+
+// expectValue checks sum of values of counter or gauge series with labels. Check is skipped
+// if gatherer is nil
+func expectValue(t *testing.T, g prometheus.Gatherer, name string, labels map[string]string, want float64) {
+	t.Helper()
+
+	if g == nil {
+		return
+	}
+	f := family(t, g, name)
+	var value float64
+	for _, m := range series(f, labels) {
+		switch f.GetType() {
+		case dto.MetricType_COUNTER:
+			value += m.GetCounter().GetValue()
+		case dto.MetricType_GAUGE:
+			value += m.GetGauge().GetValue()
+		default:
+			t.Fatalf("family %q has type %v, not counter or gauge", f.GetName(), f.GetType())
+		}
+	}
+	if value != want {
+		t.Errorf("%s%v = %v, want %v", f.GetName(), labels, value, want)
+	}
+}
+
+// expectHistogram checks count and sum of histogram series with labels and upper bounds of
+// buckets, if buckets is not nil. Check is skipped if gatherer is nil
+func expectHistogram(t *testing.T, g prometheus.Gatherer, name string, labels map[string]string,
+	count uint64, sum float64, buckets []float64,
+) {
+	t.Helper()
+
+	if g == nil {
+		return
+	}
+	f := family(t, g, name)
+	if f.GetType() != dto.MetricType_HISTOGRAM {
+		t.Fatalf("family %q has type %v, not histogram", f.GetName(), f.GetType())
+	}
+	var (
+		gotCount uint64
+		gotSum   float64
+	)
+	for _, m := range series(f, labels) {
+		h := m.GetHistogram()
+		gotCount += h.GetSampleCount()
+		gotSum += h.GetSampleSum()
+		if buckets == nil {
+			continue
+		}
+		bounds := make([]float64, 0, len(h.GetBucket()))
+		for _, b := range h.GetBucket() {
+			bounds = append(bounds, b.GetUpperBound())
+		}
+		if !equalBounds(bounds, buckets) {
+			t.Errorf("%s%v has buckets %v, want %v", f.GetName(), labels, bounds, buckets)
+		}
+	}
+	if gotCount != count {
+		t.Errorf("%s%v has count %v, want %v", f.GetName(), labels, gotCount, count)
+	}
+	if math.Abs(gotSum-sum) > 1e-9 {
+		t.Errorf("%s%v has sum %v, want %v", f.GetName(), labels, gotSum, sum)
+	}
+}
+
+func equalBounds(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+)
+
+const instrumentationName = "github.com/ydb-platform/ydb-go-sdk-prometheus/v2"
+
+// WithMeterProvider mirrors every metric into OpenTelemetry instruments with same names:
+// counters to Int64Counter, gauges to Float64Gauge, timers and histograms to Float64Histogram
+// with same buckets boundaries
+func WithMeterProvider(provider otelmetric.MeterProvider) option {
+	return func(c *config) {
+		c.meter = provider.Meter(instrumentationName)
+	}
+}
+
+func (c *config) otelCounter(name string) otelmetric.Int64Counter {
+	if c.meter == nil {
+		return nil
+	}
+	cnt, err := c.meter.Int64Counter(prometheus.BuildFQName(c.namespace, "", name))
+	if err != nil {
+		panic(err)
+	}
+
+	return cnt
+}
+
+func (c *config) otelGauge(name string) otelmetric.Float64Gauge {
+	if c.meter == nil {
+		return nil
+	}
+	g, err := c.meter.Float64Gauge(prometheus.BuildFQName(c.namespace, "", name))
+	if err != nil {
+		panic(err)
+	}
+
+	return g
+}
+
+func (c *config) otelHistogram(name, unit string, buckets []float64) otelmetric.Float64Histogram {
+	if c.meter == nil {
+		return nil
+	}
+	opts := []otelmetric.Float64HistogramOption{
+		otelmetric.WithExplicitBucketBoundaries(buckets...),
+	}
+	if unit != "" {
+		opts = append(opts, otelmetric.WithUnit(unit))
+	}
+	h, err := c.meter.Float64Histogram(prometheus.BuildFQName(c.namespace, "", name), opts...)
+	if err != nil {
+		panic(err)
+	}
+
+	return h
+}
+
+// attributes returns const labels and labels as otel measurement option
+func attributes(constLabels prometheus.Labels, labels map[string]string) otelmetric.MeasurementOption {
+	kvs := make([]attribute.KeyValue, 0, len(constLabels)+len(labels))
+	for k, v := range constLabels {
+		kvs = append(kvs, attribute.String(k, v))
+	}
+	for k, v := range labels {
+		kvs = append(kvs, attribute.String(k, v))
+	}
+	sort.Slice(kvs, func(i, j int) bool {
+		return kvs[i].Key < kvs[j].Key
+	})
+
+	return otelmetric.WithAttributeSet(attribute.NewSet(kvs...))
+}
+
+type otelCounter struct {
+	prometheus.Counter
+	otel  otelmetric.Int64Counter
+	attrs otelmetric.MeasurementOption
+}
+
+func (c *otelCounter) Inc() {
+	c.Counter.Inc()
+	c.otel.Add(context.Background(), 1, c.attrs)
+}
+
+// otelGauge mirrors prometheus gauge into otel gauge. Changes of gauges of vector are serialized
+// with mutex of vector, so otel gauge records values in same order as prometheus gauge changes
+type otelGauge struct {
+	prometheus.Gauge
+	otel  otelmetric.Float64Gauge
+	attrs otelmetric.MeasurementOption
+	m     *sync.Mutex
+}
+
+func (g *otelGauge) Set(value float64) {
+	g.m.Lock()
+	defer g.m.Unlock()
+	g.Gauge.Set(value)
+	g.otel.Record(context.Background(), value, g.attrs)
+}
+
+// Add records current value of prometheus gauge, because otel gauge has no relative changes
+func (g *otelGauge) Add(delta float64) {
+	g.m.Lock()
+	defer g.m.Unlock()
+	g.Gauge.Add(delta)
+	var m dto.Metric
+	if err := g.Gauge.Write(&m); err == nil {
+		g.otel.Record(context.Background(), m.GetGauge().GetValue(), g.attrs)
+	}
+}
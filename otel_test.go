@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestMeterProvider(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	c := Config(prometheus.NewRegistry(),
+		WithMeterProvider(provider),
+		WithTimerBuckets([]float64{0.5, 1}),
+	).WithSystem("ydb")
+
+	c.CounterVec("errors", "status").With(map[string]string{"status": "OK"}).Inc()
+	conns := c.GaugeVec("conns").With(nil)
+	conns.Set(2)
+	conns.Add(3)
+	c.TimerVec("latency").With(nil).Record(750 * time.Millisecond)
+	c.HistogramVec("attempts", []float64{1, 2, 3}).With(nil).Record(2)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	if len(rm.ScopeMetrics) != 1 {
+		t.Fatalf("unexpected scopes count: %d", len(rm.ScopeMetrics))
+	}
+	got := make(map[string]metricdata.Aggregation)
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		got[m.Name] = m.Data
+	}
+
+	sum, ok := got["ydb_go_sdk_ydb_errors"].(metricdata.Sum[int64])
+	if !ok || len(sum.DataPoints) != 1 || sum.DataPoints[0].Value != 1 ||
+		sum.DataPoints[0].Attributes != attribute.NewSet(attribute.String("status", "OK")) {
+		t.Errorf("unexpected counter: %+v", got["ydb_go_sdk_ydb_errors"])
+	}
+	gauge, ok := got["ydb_go_sdk_ydb_conns"].(metricdata.Gauge[float64])
+	if !ok || len(gauge.DataPoints) != 1 || gauge.DataPoints[0].Value != 5 {
+		t.Errorf("unexpected gauge: %+v", got["ydb_go_sdk_ydb_conns"])
+	}
+	for name, bounds := range map[string][]float64{
+		"ydb_go_sdk_ydb_latency":  {0.5, 1},
+		"ydb_go_sdk_ydb_attempts": {1, 2, 3},
+	} {
+		h, ok := got[name].(metricdata.Histogram[float64])
+		if !ok || len(h.DataPoints) != 1 || h.DataPoints[0].Count != 1 ||
+			len(h.DataPoints[0].Bounds) != len(bounds) {
+			t.Errorf("unexpected histogram %q: %+v", name, got[name])
+
+			continue
+		}
+		for i := range bounds {
+			if h.DataPoints[0].Bounds[i] != bounds[i] {
+				t.Errorf("unexpected bounds of histogram %q: %v", name, h.DataPoints[0].Bounds)
+			}
+		}
+	}
+}
+
+func TestMeterProviderConcurrentGauge(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	c := Config(prometheus.NewRegistry(),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	).WithSystem("ydb")
+
+	const goroutines, iterations = 8, 100
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				c.GaugeVec("conns").With(nil).Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	gauge, ok := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Gauge[float64])
+	if !ok || len(gauge.DataPoints) != 1 || gauge.DataPoints[0].Value != goroutines*iterations {
+		t.Errorf("unexpected gauge: %+v", rm.ScopeMetrics[0].Metrics[0].Data)
+	}
+}
+
+func TestMeterProviderConstLabels(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	c := Config(prometheus.NewRegistry(),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		WithConstLabels(prometheus.Labels{"service": "bench"}),
+	).WithSystem("ydb")
+	c.CounterVec("errors", "status").With(map[string]string{"status": "OK"}).Inc()
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	sum, ok := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+	expected := attribute.NewSet(attribute.String("service", "bench"), attribute.String("status", "OK"))
+	if !ok || len(sum.DataPoints) != 1 || sum.DataPoints[0].Attributes != expected {
+		t.Errorf("unexpected counter: %+v", rm.ScopeMetrics[0].Metrics[0].Data)
+	}
+}
@@ -0,0 +1,298 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ydb-platform/ydb-go-genproto/Ydb_Query_V1"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb_Query"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	ydbConfig "github.com/ydb-platform/ydb-go-sdk/v3/config"
+	"github.com/ydb-platform/ydb-go-sdk/v3/metrics"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+const defaultQueryLabel = "none"
+
+var (
+	defaultRowsBuckets = prometheus.ExponentialBuckets(1, 4, 11)
+)
+
+type queryLabelKey struct{}
+
+// WithQueryLabel returns a copy of ctx with label of query, which used as value of label `query_label`
+// in query service metrics. Labels must be a bounded set of values (for example, names of application queries)
+func WithQueryLabel(ctx context.Context, label string) context.Context {
+	return context.WithValue(ctx, queryLabelKey{}, label)
+}
+
+// queryLabel returns query label from context or default label if context has no label
+func queryLabel(ctx *context.Context) string {
+	if ctx == nil || *ctx == nil {
+		return defaultQueryLabel
+	}
+	if label, ok := (*ctx).Value(queryLabelKey{}).(string); ok && label != "" {
+		return label
+	}
+
+	return defaultQueryLabel
+}
+
+// query makes query service trace with metrics of session pool, execution of queries and
+// receiving of first part of result
+//
+//nolint:funlen
+func query(config metrics.Config) (t trace.Query) {
+	config = config.WithSystem("query")
+	getWait := config.WithSystem("pool").TimerVec("get_wait", "status")
+	sessionAge := config.WithSystem("session").TimerVec("age")
+	execute := config.TimerVec("execute_latency", "method", "query_label", "status")
+	firstPart := config.WithSystem("result").TimerVec("first_part_latency", "query_label", "status")
+
+	var (
+		mu       sync.Mutex
+		sessions = make(map[string]time.Time)
+	)
+	onExecute := func(method string, ctx *context.Context) func(err error) {
+		if config.Details()&trace.QueryEvents == 0 {
+			return func(error) {}
+		}
+		label := queryLabel(ctx)
+		start := time.Now()
+
+		return func(err error) {
+			execute.With(map[string]string{
+				"method":      method,
+				"query_label": label,
+				"status":      errorBrief(err),
+			}).Record(time.Since(start))
+		}
+	}
+
+	t.OnPoolGet = func(info trace.QueryPoolGetStartInfo) func(trace.QueryPoolGetDoneInfo) {
+		if config.Details()&trace.QueryPoolEvents == 0 {
+			return nil
+		}
+		start := time.Now()
+
+		return func(info trace.QueryPoolGetDoneInfo) {
+			getWait.With(map[string]string{
+				"status": errorBrief(info.Error),
+			}).Record(time.Since(start))
+		}
+	}
+	t.OnSessionCreate = func(info trace.QuerySessionCreateStartInfo) func(trace.QuerySessionCreateDoneInfo) {
+		return func(info trace.QuerySessionCreateDoneInfo) {
+			if info.Error == nil && info.Session != nil && config.Details()&trace.QuerySessionEvents != 0 {
+				mu.Lock()
+				defer mu.Unlock()
+				sessions[info.Session.ID()] = time.Now()
+			}
+		}
+	}
+	t.OnSessionDelete = func(info trace.QuerySessionDeleteStartInfo) func(trace.QuerySessionDeleteDoneInfo) {
+		if info.Session == nil {
+			return nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if created, has := sessions[info.Session.ID()]; has {
+			delete(sessions, info.Session.ID())
+			sessionAge.With(nil).Record(time.Since(created))
+		}
+
+		return nil
+	}
+	t.OnDo = func(info trace.QueryDoStartInfo) func(trace.QueryDoDoneInfo) {
+		onDone := onExecute("do", info.Context)
+
+		return func(info trace.QueryDoDoneInfo) {
+			onDone(info.Error)
+		}
+	}
+	t.OnDoTx = func(info trace.QueryDoTxStartInfo) func(trace.QueryDoTxDoneInfo) {
+		onDone := onExecute("do_tx", info.Context)
+
+		return func(info trace.QueryDoTxDoneInfo) {
+			onDone(info.Error)
+		}
+	}
+	t.OnExec = func(info trace.QueryExecStartInfo) func(trace.QueryExecDoneInfo) {
+		onDone := onExecute("exec", info.Context)
+
+		return func(info trace.QueryExecDoneInfo) {
+			onDone(info.Error)
+		}
+	}
+	t.OnQuery = func(info trace.QueryQueryStartInfo) func(trace.QueryQueryDoneInfo) {
+		onDone := onExecute("query", info.Context)
+
+		return func(info trace.QueryQueryDoneInfo) {
+			onDone(info.Error)
+		}
+	}
+	t.OnQueryResultSet = func(info trace.QueryQueryResultSetStartInfo) func(trace.QueryQueryResultSetDoneInfo) {
+		onDone := onExecute("query_result_set", info.Context)
+
+		return func(info trace.QueryQueryResultSetDoneInfo) {
+			onDone(info.Error)
+		}
+	}
+	t.OnQueryRow = func(info trace.QueryQueryRowStartInfo) func(trace.QueryQueryRowDoneInfo) {
+		onDone := onExecute("query_row", info.Context)
+
+		return func(info trace.QueryQueryRowDoneInfo) {
+			onDone(info.Error)
+		}
+	}
+	t.OnResultNew = func(info trace.QueryResultNewStartInfo) func(trace.QueryResultNewDoneInfo) {
+		if config.Details()&trace.QueryResultEvents == 0 {
+			return nil
+		}
+		label := queryLabel(info.Context)
+		start := time.Now()
+
+		return func(info trace.QueryResultNewDoneInfo) {
+			firstPart.With(map[string]string{
+				"query_label": label,
+				"status":      errorBrief(info.Error),
+			}).Record(time.Since(start))
+		}
+	}
+
+	return t
+}
+
+// queryTx makes driver trace with latency of commit and rollback of query service transactions
+func queryTx(config metrics.Config) (t trace.Driver) {
+	txConfig := config.WithSystem("query").WithSystem("tx")
+	latencies := map[string]metrics.TimerVec{
+		Ydb_Query_V1.QueryService_CommitTransaction_FullMethodName: txConfig.WithSystem("commit").TimerVec(
+			"latency", "query_label", "status",
+		),
+		Ydb_Query_V1.QueryService_RollbackTransaction_FullMethodName: txConfig.WithSystem("rollback").TimerVec(
+			"latency", "query_label", "status",
+		),
+	}
+
+	t.OnConnInvoke = func(info trace.DriverConnInvokeStartInfo) func(trace.DriverConnInvokeDoneInfo) {
+		if config.Details()&trace.QueryTransactionEvents == 0 {
+			return nil
+		}
+		latency, has := latencies[string(info.Method)]
+		if !has {
+			return nil
+		}
+		label := queryLabel(info.Context)
+		start := time.Now()
+
+		return func(info trace.DriverConnInvokeDoneInfo) {
+			latency.With(map[string]string{
+				"query_label": label,
+				"status":      errorBrief(info.Error),
+			}).Record(time.Since(start))
+		}
+	}
+
+	return t
+}
+
+// withQueryResultSets returns driver option which records number of rows and bytes of result sets of query service
+func withQueryResultSets(config metrics.Config) ydb.Option {
+	if config.Details()&trace.QueryResultEvents == 0 {
+		return nil
+	}
+	resultConfig := config.WithSystem("query").WithSystem("result")
+	rows := resultConfig.HistogramVec("set_rows", defaultRowsBuckets, "query_label")
+	bytes := resultConfig.HistogramVec("set_bytes", defaultBytesBuckets, "query_label")
+
+	return ydb.With(ydbConfig.WithGrpcOptions(grpc.WithChainStreamInterceptor(
+		func(
+			ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+			streamer grpc.Streamer, opts ...grpc.CallOption,
+		) (grpc.ClientStream, error) {
+			stream, err := streamer(ctx, desc, cc, method, opts...)
+			if err != nil || method != Ydb_Query_V1.QueryService_ExecuteQuery_FullMethodName ||
+				config.Details()&trace.QueryResultEvents == 0 {
+				return stream, err
+			}
+
+			return newQueryResultStream(stream, queryLabel(&ctx), rows, bytes), nil
+		},
+	)))
+}
+
+// queryResultStream accumulates rows and bytes of result set from result parts and records them
+// when next result set begins or stream ends
+type queryResultStream struct {
+	grpc.ClientStream
+
+	label       string
+	rows, bytes metrics.HistogramVec
+
+	m        sync.Mutex
+	index    int64
+	setRows  int
+	setBytes int
+}
+
+func newQueryResultStream(
+	stream grpc.ClientStream, label string, rows, bytes metrics.HistogramVec,
+) *queryResultStream {
+	s := &queryResultStream{
+		ClientStream: stream,
+		label:        label,
+		rows:         rows,
+		bytes:        bytes,
+		index:        -1,
+	}
+	// result can be abandoned before end of stream, so last result set also recorded on end of stream context
+	context.AfterFunc(stream.Context(), s.flush)
+
+	return s
+}
+
+func (s *queryResultStream) RecvMsg(m interface{}) error {
+	if err := s.ClientStream.RecvMsg(m); err != nil {
+		s.flush()
+
+		return err
+	}
+	part, ok := m.(*Ydb_Query.ExecuteQueryResponsePart)
+	if !ok || part.GetResultSet() == nil {
+		return nil
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	if part.GetResultSetIndex() != s.index {
+		s.record()
+		s.index = part.GetResultSetIndex()
+	}
+	s.setRows += len(part.GetResultSet().GetRows())
+	s.setBytes += proto.Size(part.GetResultSet())
+
+	return nil
+}
+
+func (s *queryResultStream) flush() {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.record()
+}
+
+// record records accumulated result set. Must be called under lock
+func (s *queryResultStream) record() {
+	if s.index < 0 {
+		return
+	}
+	labels := map[string]string{
+		"query_label": s.label,
+	}
+	s.rows.With(labels).Record(float64(s.setRows))
+	s.bytes.With(labels).Record(float64(s.setBytes))
+	s.index, s.setRows, s.setBytes = -1, 0, 0
+}
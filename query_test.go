@@ -0,0 +1,204 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ydb-platform/ydb-go-genproto/Ydb_Query_V1"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb_Query"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/ydb-platform/ydb-go-sdk-prometheus/v2/ydbpromtest"
+)
+
+func TestQuery(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	q := query(Config(registry).WithSystem("ydb"))
+
+	ctx := WithQueryLabel(context.Background(), "select_users")
+	session := testSession{id: "1", status: "idle"}
+
+	q.OnPoolGet(trace.QueryPoolGetStartInfo{Context: &ctx})(trace.QueryPoolGetDoneInfo{Session: session})
+	q.OnSessionCreate(trace.QuerySessionCreateStartInfo{Context: &ctx})(trace.QuerySessionCreateDoneInfo{
+		Session: session,
+	})
+	q.OnSessionDelete(trace.QuerySessionDeleteStartInfo{Context: &ctx, Session: session})
+	q.OnDo(trace.QueryDoStartInfo{Context: &ctx})(trace.QueryDoDoneInfo{})
+	q.OnDoTx(trace.QueryDoTxStartInfo{Context: &ctx})(trace.QueryDoTxDoneInfo{Error: context.Canceled})
+	q.OnResultNew(trace.QueryResultNewStartInfo{Context: &ctx})(trace.QueryResultNewDoneInfo{})
+
+	background := context.Background()
+	q.OnExec(trace.QueryExecStartInfo{Context: &background})(trace.QueryExecDoneInfo{})
+
+	assertValue(t, registry, "ydb_go_sdk_ydb_query_pool_get_wait", map[string]string{"status": "OK"}, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_query_session_age", nil, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_query_execute_latency", map[string]string{
+		"method":      "do",
+		"query_label": "select_users",
+		"status":      "OK",
+	}, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_query_execute_latency", map[string]string{
+		"method":      "do_tx",
+		"query_label": "select_users",
+		"status":      "context/Canceled",
+	}, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_query_execute_latency", map[string]string{
+		"method":      "exec",
+		"query_label": "none",
+		"status":      "OK",
+	}, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_query_result_first_part_latency", map[string]string{
+		"query_label": "select_users",
+		"status":      "OK",
+	}, 1)
+}
+
+func TestQueryTx(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	d := queryTx(Config(registry).WithSystem("ydb"))
+
+	ctx := WithQueryLabel(context.Background(), "update_users")
+	for _, method := range []string{
+		Ydb_Query_V1.QueryService_CommitTransaction_FullMethodName,
+		Ydb_Query_V1.QueryService_RollbackTransaction_FullMethodName,
+		Ydb_Query_V1.QueryService_BeginTransaction_FullMethodName,
+	} {
+		if onDone := d.OnConnInvoke(trace.DriverConnInvokeStartInfo{
+			Context: &ctx,
+			Method:  trace.Method(method),
+		}); onDone != nil {
+			onDone(trace.DriverConnInvokeDoneInfo{})
+		}
+	}
+
+	labels := map[string]string{"query_label": "update_users", "status": "OK"}
+	assertValue(t, registry, "ydb_go_sdk_ydb_query_tx_commit_latency", labels, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_query_tx_rollback_latency", labels, 1)
+}
+
+type testQueryStream struct {
+	grpc.ClientStream
+
+	ctx   context.Context
+	parts []*Ydb_Query.ExecuteQueryResponsePart
+}
+
+func (s *testQueryStream) Context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+
+	return s.ctx
+}
+
+func (s *testQueryStream) RecvMsg(m interface{}) error {
+	if len(s.parts) == 0 {
+		return io.EOF
+	}
+	proto.Merge(m.(proto.Message), s.parts[0])
+	s.parts = s.parts[1:]
+
+	return nil
+}
+
+func TestQueryResultSets(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	config := Config(registry).WithSystem("ydb")
+	resultConfig := config.WithSystem("query").WithSystem("result")
+
+	resultSet := func(index int64, rows int) *Ydb_Query.ExecuteQueryResponsePart {
+		return &Ydb_Query.ExecuteQueryResponsePart{
+			ResultSetIndex: index,
+			ResultSet: &Ydb.ResultSet{
+				Rows: make([]*Ydb.Value, rows),
+			},
+		}
+	}
+	stream := newQueryResultStream(
+		&testQueryStream{parts: []*Ydb_Query.ExecuteQueryResponsePart{
+			resultSet(0, 2),
+			resultSet(0, 3),
+			{},
+			resultSet(1, 7),
+		}},
+		"select_users",
+		resultConfig.HistogramVec("set_rows", defaultRowsBuckets, "query_label"),
+		resultConfig.HistogramVec("set_bytes", defaultBytesBuckets, "query_label"),
+	)
+	for {
+		if err := stream.RecvMsg(&Ydb_Query.ExecuteQueryResponsePart{}); err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Fatal(err)
+			}
+
+			break
+		}
+	}
+
+	labels := map[string]string{"query_label": "select_users"}
+	assertValue(t, registry, "ydb_go_sdk_ydb_query_result_set_rows", labels, 2)
+	assertValue(t, registry, "ydb_go_sdk_ydb_query_result_set_bytes", labels, 2)
+	rows, err := ydbpromtest.HistogramSum(registry, "ydb_go_sdk_ydb_query_result_set_rows", labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows != 12 {
+		t.Errorf("unexpected rows of result sets: %v, expected 12", rows)
+	}
+}
+
+func TestQueryResultSetsAbandoned(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	resultConfig := Config(registry).WithSystem("ydb").WithSystem("query").WithSystem("result")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := newQueryResultStream(
+		&testQueryStream{ctx: ctx, parts: []*Ydb_Query.ExecuteQueryResponsePart{
+			{ResultSet: &Ydb.ResultSet{Rows: make([]*Ydb.Value, 3)}},
+			{ResultSet: &Ydb.ResultSet{Rows: make([]*Ydb.Value, 5)}},
+		}},
+		"select_users",
+		resultConfig.HistogramVec("set_rows", defaultRowsBuckets, "query_label"),
+		resultConfig.HistogramVec("set_bytes", defaultBytesBuckets, "query_label"),
+	)
+	if err := stream.RecvMsg(&Ydb_Query.ExecuteQueryResponsePart{}); err != nil {
+		t.Fatal(err)
+	}
+	// result abandoned without reading until end of stream
+	cancel()
+
+	labels := map[string]string{"query_label": "select_users"}
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		count, err := ydbpromtest.HistogramCount(registry, "ydb_go_sdk_ydb_query_result_set_rows", labels)
+		if err == nil && count == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("result set of abandoned stream not recorded: %v, %v", count, err)
+		}
+	}
+	rows, err := ydbpromtest.HistogramSum(registry, "ydb_go_sdk_ydb_query_result_set_rows", labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows != 3 {
+		t.Errorf("unexpected rows of result set: %v, expected 3", rows)
+	}
+}
+
+func TestQueryResultSetsDetails(t *testing.T) {
+	config := Config(prometheus.NewRegistry(), WithDetailer(trace.QueryEvents^trace.QueryResultEvents))
+	if withQueryResultSets(config) != nil {
+		t.Fatal("interceptor installed without query result details")
+	}
+	if withQueryResultSets(Config(prometheus.NewRegistry())) == nil {
+		t.Fatal("interceptor not installed with query result details")
+	}
+}
@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ydb-platform/ydb-go-genproto/Ydb_RateLimiter_V1"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb_RateLimiter"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	ydbConfig "github.com/ydb-platform/ydb-go-sdk/v3/config"
+	"github.com/ydb-platform/ydb-go-sdk/v3/metrics"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+	"google.golang.org/grpc"
+)
+
+var (
+	defaultUnitsBuckets = prometheus.ExponentialBuckets(1, 4, 11)
+)
+
+type ratelimiterMetrics struct {
+	latency metrics.TimerVec
+	units   metrics.HistogramVec
+	denied  metrics.CounterVec
+
+	resources *boundedLabel
+}
+
+func newRatelimiterMetrics(config metrics.Config) *ratelimiterMetrics {
+	config = config.WithSystem("ratelimiter")
+
+	return &ratelimiterMetrics{
+		latency:   config.TimerVec("acquire_latency", "node", "resource", "outcome"),
+		units:     config.HistogramVec("requested_units", defaultUnitsBuckets, "node", "resource", "type"),
+		denied:    config.CounterVec("denied", "node", "resource"),
+		resources: newBoundedLabel(config),
+	}
+}
+
+// labels returns labels of resource, resource label is guarded by limit of label values
+func (m *ratelimiterMetrics) labels(node, resource string) map[string]string {
+	return map[string]string{
+		"node":     node,
+		"resource": m.resources.value(node+"/"+resource, resource),
+	}
+}
+
+// ratelimiter returns driver option with metrics of acquiring of rate limiter resources
+func ratelimiter(config metrics.Config) ydb.Option {
+	m := newRatelimiterMetrics(config)
+
+	return ydb.With(ydbConfig.WithGrpcOptions(grpc.WithChainUnaryInterceptor(m.unaryInterceptor(config))))
+}
+
+func (m *ratelimiterMetrics) unaryInterceptor(config metrics.Config) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+	) error {
+		request, ok := req.(*Ydb_RateLimiter.AcquireResourceRequest)
+		if !ok || method != Ydb_RateLimiter_V1.RateLimiterService_AcquireResource_FullMethodName ||
+			config.Details()&trace.RatelimiterEvents == 0 {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		start := time.Now()
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		labels := m.labels(request.GetCoordinationNodePath(), request.GetResourcePath())
+		outcome := ratelimiterOutcome(reply, err)
+		if outcome == "denied" {
+			m.denied.With(labels).Inc()
+		}
+		if request.GetUsed() > 0 {
+			m.units.With(withLabel(labels, "type", "report")).Record(float64(request.GetUsed()))
+		} else {
+			m.units.With(withLabel(labels, "type", "acquire")).Record(float64(request.GetRequired()))
+		}
+		m.latency.With(withLabel(labels, "outcome", outcome)).Record(time.Since(start))
+
+		return err
+	}
+}
+
+// ratelimiterOutcome returns outcome of acquiring of resource: "acquired", "denied" (no units
+// available until timeout) or "error"
+func ratelimiterOutcome(reply interface{}, err error) string {
+	if err != nil {
+		return "error"
+	}
+	response, ok := reply.(*Ydb_RateLimiter.AcquireResourceResponse)
+	if !ok {
+		return "error"
+	}
+	switch response.GetOperation().GetStatus() {
+	case Ydb.StatusIds_SUCCESS:
+		return "acquired"
+	case Ydb.StatusIds_TIMEOUT, Ydb.StatusIds_CANCELLED:
+		return "denied"
+	default:
+		return "error"
+	}
+}
+
+// withLabel returns copy of labels with additional label
+func withLabel(labels map[string]string, name, value string) map[string]string {
+	l := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		l[k] = v
+	}
+	l[name] = value
+
+	return l
+}
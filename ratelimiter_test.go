@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ydb-platform/ydb-go-genproto/Ydb_RateLimiter_V1"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb_Operations"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb_RateLimiter"
+	"google.golang.org/grpc"
+)
+
+func TestRatelimiterInterceptor(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	config := Config(registry).WithSystem("ydb")
+	interceptor := newRatelimiterMetrics(config).unaryInterceptor(config)
+
+	acquire := func(request *Ydb_RateLimiter.AcquireResourceRequest, status Ydb.StatusIds_StatusCode, err error) {
+		reply := &Ydb_RateLimiter.AcquireResourceResponse{}
+		_ = interceptor(context.Background(), Ydb_RateLimiter_V1.RateLimiterService_AcquireResource_FullMethodName,
+			request, reply, nil,
+			func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+				opts ...grpc.CallOption,
+			) error {
+				reply.(*Ydb_RateLimiter.AcquireResourceResponse).Operation = &Ydb_Operations.Operation{Status: status}
+
+				return err
+			},
+		)
+	}
+	request := func(units uint64) *Ydb_RateLimiter.AcquireResourceRequest {
+		return &Ydb_RateLimiter.AcquireResourceRequest{
+			CoordinationNodePath: "/local/node",
+			ResourcePath:         "resource",
+			Units:                &Ydb_RateLimiter.AcquireResourceRequest_Required{Required: units},
+		}
+	}
+
+	acquire(request(10), Ydb.StatusIds_SUCCESS, nil)
+	acquire(request(20), Ydb.StatusIds_TIMEOUT, nil)
+	acquire(request(30), Ydb.StatusIds_SUCCESS, errors.New("transport"))
+	acquire(&Ydb_RateLimiter.AcquireResourceRequest{
+		CoordinationNodePath: "/local/node",
+		ResourcePath:         "resource",
+		Units:                &Ydb_RateLimiter.AcquireResourceRequest_Used{Used: 5},
+	}, Ydb.StatusIds_SUCCESS, nil)
+
+	labels := map[string]string{"node": "/local/node", "resource": "resource"}
+	for outcome, expected := range map[string]float64{"acquired": 2, "denied": 1, "error": 1} {
+		assertValue(t, registry, "ydb_go_sdk_ydb_ratelimiter_acquire_latency",
+			withLabel(labels, "outcome", outcome), expected)
+	}
+	for typ, expected := range map[string]float64{"acquire": 3, "report": 1} {
+		assertValue(t, registry, "ydb_go_sdk_ydb_ratelimiter_requested_units", withLabel(labels, "type", typ), expected)
+	}
+	assertValue(t, registry, "ydb_go_sdk_ydb_ratelimiter_denied", labels, 1)
+}
+
+func TestRatelimiterResourceLimit(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := newRatelimiterMetrics(Config(registry, WithLabelValuesLimit(1)).WithSystem("ydb"))
+
+	if v := m.labels("/local/node", "first")["resource"]; v != "first" {
+		t.Errorf("unexpected resource label: %q", v)
+	}
+	if v := m.labels("/local/node", "second")["resource"]; v != otherLabelValue {
+		t.Errorf("unexpected resource label: %q", v)
+	}
+}
@@ -0,0 +1,112 @@
+package remotewrite
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers of prometheus remote write protocol (prompb.WriteRequest)
+const (
+	writeRequestTimeseries = 1
+	timeSeriesLabels       = 1
+	timeSeriesSamples      = 2
+	labelName              = 1
+	labelValue             = 2
+	sampleValue            = 1
+	sampleTimestamp        = 2
+)
+
+type label struct {
+	name, value string
+}
+
+type sample struct {
+	labels []label
+	value  float64
+}
+
+// samples converts gathered metric families to samples with same series names as
+// in prometheus exposition format
+func samples(families []*dto.MetricFamily) []sample {
+	var samples []sample
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			labels := make([]label, 0, len(m.GetLabel())+1)
+			for _, l := range m.GetLabel() {
+				labels = append(labels, label{name: l.GetName(), value: l.GetValue()})
+			}
+			add := func(name string, value float64, extra ...label) {
+				ls := make([]label, 0, len(labels)+len(extra)+1)
+				ls = append(ls, label{name: "__name__", value: name})
+				ls = append(ls, labels...)
+				ls = append(ls, extra...)
+				sort.Slice(ls, func(i, j int) bool {
+					return ls[i].name < ls[j].name
+				})
+				samples = append(samples, sample{labels: ls, value: value})
+			}
+			switch {
+			case m.GetCounter() != nil:
+				add(f.GetName(), m.GetCounter().GetValue())
+			case m.GetGauge() != nil:
+				add(f.GetName(), m.GetGauge().GetValue())
+			case m.GetUntyped() != nil:
+				add(f.GetName(), m.GetUntyped().GetValue())
+			case m.GetHistogram() != nil:
+				h := m.GetHistogram()
+				for _, b := range h.GetBucket() {
+					add(f.GetName()+"_bucket", float64(b.GetCumulativeCount()),
+						label{name: "le", value: strconv.FormatFloat(b.GetUpperBound(), 'g', -1, 64)},
+					)
+				}
+				add(f.GetName()+"_bucket", float64(h.GetSampleCount()), label{name: "le", value: "+Inf"})
+				add(f.GetName()+"_sum", h.GetSampleSum())
+				add(f.GetName()+"_count", float64(h.GetSampleCount()))
+			case m.GetSummary() != nil:
+				s := m.GetSummary()
+				for _, q := range s.GetQuantile() {
+					add(f.GetName(), q.GetValue(),
+						label{name: "quantile", value: strconv.FormatFloat(q.GetQuantile(), 'g', -1, 64)},
+					)
+				}
+				add(f.GetName()+"_sum", s.GetSampleSum())
+				add(f.GetName()+"_count", float64(s.GetSampleCount()))
+			}
+		}
+	}
+
+	return samples
+}
+
+// marshalWriteRequest encodes samples as protobuf prompb.WriteRequest
+func marshalWriteRequest(samples []sample, timestamp int64) []byte {
+	var b []byte
+	for _, s := range samples {
+		var ts []byte
+		for _, l := range s.labels {
+			var lb []byte
+			lb = protowire.AppendTag(lb, labelName, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.name)
+			lb = protowire.AppendTag(lb, labelValue, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.value)
+			ts = protowire.AppendTag(ts, timeSeriesLabels, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, lb)
+		}
+		var sb []byte
+		sb = protowire.AppendTag(sb, sampleValue, protowire.Fixed64Type)
+		sb = protowire.AppendFixed64(sb, math.Float64bits(s.value))
+		sb = protowire.AppendTag(sb, sampleTimestamp, protowire.VarintType)
+		sb = protowire.AppendVarint(sb, uint64(timestamp))
+		ts = protowire.AppendTag(ts, timeSeriesSamples, protowire.BytesType)
+		ts = protowire.AppendBytes(ts, sb)
+
+		b = protowire.AppendTag(b, writeRequestTimeseries, protowire.BytesType)
+		b = protowire.AppendBytes(b, ts)
+	}
+
+	return b
+}
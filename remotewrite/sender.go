@@ -0,0 +1,262 @@
+// Package remotewrite pushes metrics of prometheus adapter for ydb-go-sdk using
+// prometheus remote write protocol. It is useful if pull-based scraping is impossible
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ydb-platform/ydb-go-sdk-prometheus/v2/internal/retry"
+)
+
+const (
+	defaultInterval     = 15 * time.Second
+	defaultTimeout      = 15 * time.Second
+	defaultQueueSize    = 100
+	defaultMaxRetries   = 3
+	defaultRetryBackoff = time.Second
+)
+
+// ErrQueueFull returned by Enqueue if queue is full. Oldest request is dropped in this case
+var ErrQueueFull = errors.New("remotewrite: queue is full, oldest request dropped")
+
+// Config is a config of Sender
+type Config struct {
+	// URL of remote write endpoint. Required
+	URL string
+
+	// Gatherer is a source of metrics (registry passed to WithTraces). Required
+	Gatherer prometheus.Gatherer
+
+	// Interval between gathers. Default is 15 seconds
+	Interval time.Duration
+
+	// Timeout of single write request. Default is 15 seconds
+	Timeout time.Duration
+
+	// QueueSize is a max count of gathered but not sent requests. Default is 100
+	QueueSize int
+
+	// MaxRetries is a max count of retries of failed request. Default is 3, negative value disables retries
+	MaxRetries int
+
+	// RetryBackoff is a delay before first retry, each next delay doubled. Default is 1 second
+	RetryBackoff time.Duration
+
+	// Headers added to each request (for example, Authorization)
+	Headers map[string]string
+
+	// HTTPClient for requests. Default is http.DefaultClient
+	HTTPClient *http.Client
+
+	// OnError called on each failed gather or send in Run. Errors are ignored if nil
+	OnError func(err error)
+}
+
+type request struct {
+	body []byte
+}
+
+// Sender gathers metrics and pushes it to remote write endpoint
+type Sender struct {
+	config Config
+	now    func() time.Time
+
+	mu    sync.Mutex
+	queue []*request
+	ready chan struct{}
+}
+
+// NewSender returns Sender by config
+func NewSender(c *Config) (*Sender, error) {
+	if c.URL == "" {
+		return nil, errors.New("remotewrite: empty url")
+	}
+	if c.Gatherer == nil {
+		return nil, errors.New("remotewrite: nil gatherer")
+	}
+	s := &Sender{
+		config: *c,
+		now:    time.Now,
+		ready:  make(chan struct{}, 1),
+	}
+	if s.config.Interval <= 0 {
+		s.config.Interval = defaultInterval
+	}
+	if s.config.Timeout <= 0 {
+		s.config.Timeout = defaultTimeout
+	}
+	if s.config.QueueSize <= 0 {
+		s.config.QueueSize = defaultQueueSize
+	}
+	if s.config.MaxRetries == 0 {
+		s.config.MaxRetries = defaultMaxRetries
+	}
+	if s.config.RetryBackoff <= 0 {
+		s.config.RetryBackoff = defaultRetryBackoff
+	}
+	if s.config.HTTPClient == nil {
+		s.config.HTTPClient = http.DefaultClient
+	}
+
+	return s, nil
+}
+
+// Run gathers metrics every interval and sends queued requests until ctx done
+func (s *Sender) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.ready:
+				if err := s.Flush(ctx); err != nil {
+					s.onError(err)
+				}
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Enqueue(); err != nil {
+				s.onError(err)
+			}
+		}
+	}
+}
+
+func (s *Sender) onError(err error) {
+	if s.config.OnError != nil {
+		s.config.OnError(err)
+	}
+}
+
+// Enqueue gathers metrics and puts encoded request to queue
+func (s *Sender) Enqueue() error {
+	families, err := s.config.Gatherer.Gather()
+	if err != nil {
+		return fmt.Errorf("remotewrite: gather failed: %w", err)
+	}
+	r := &request{
+		body: snappy.Encode(nil, marshalWriteRequest(samples(families), s.now().UnixMilli())),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer func() {
+		select {
+		case s.ready <- struct{}{}:
+		default:
+		}
+	}()
+	if len(s.queue) >= s.config.QueueSize {
+		s.queue = append(s.queue[1:], r)
+
+		return ErrQueueFull
+	}
+	s.queue = append(s.queue, r)
+
+	return nil
+}
+
+// Flush sends all queued requests. Request which failed after all retries is kept in queue
+func (s *Sender) Flush(ctx context.Context) error {
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+
+			return nil
+		}
+		r := s.queue[0]
+		s.mu.Unlock()
+
+		if err := s.sendWithRetry(ctx, r.body); err != nil {
+			if retry.IsRetryable(err) {
+				return err
+			}
+			// not retryable request will never succeed, so drop it
+			s.pop(r)
+
+			return err
+		}
+		s.pop(r)
+	}
+}
+
+// pop removes request from queue head if it was not dropped already
+func (s *Sender) pop(r *request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queue) > 0 && s.queue[0] == r {
+		s.queue = s.queue[1:]
+	}
+}
+
+// Send gathers metrics and sends it immediately, bypassing queue
+func (s *Sender) Send(ctx context.Context) error {
+	families, err := s.config.Gatherer.Gather()
+	if err != nil {
+		return fmt.Errorf("remotewrite: gather failed: %w", err)
+	}
+
+	return s.sendWithRetry(ctx, snappy.Encode(nil, marshalWriteRequest(samples(families), s.now().UnixMilli())))
+}
+
+func (s *Sender) sendWithRetry(ctx context.Context, body []byte) error {
+	return retry.Do(ctx, s.config.MaxRetries, s.config.RetryBackoff, func(ctx context.Context) error {
+		return s.send(ctx, body)
+	})
+}
+
+func (s *Sender) send(ctx context.Context, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("remotewrite: create request failed: %w", err)
+	}
+	for k, v := range s.config.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	resp, err := s.config.HTTPClient.Do(req)
+	if err != nil {
+		return retry.Retryable(fmt.Errorf("remotewrite: send failed: %w", err))
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	err = fmt.Errorf("remotewrite: send failed with status %q: %s", resp.Status, msg)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return retry.Retryable(err)
+	}
+
+	return err
+}
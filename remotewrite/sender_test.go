@@ -0,0 +1,238 @@
+package remotewrite
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	metrics "github.com/ydb-platform/ydb-go-sdk-prometheus/v2"
+)
+
+// receiver is a local remote write endpoint which decodes received samples
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	requests int
+	samples  []string
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests++
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+
+		return
+	}
+	if req.Header.Get("Content-Encoding") != "snappy" ||
+		req.Header.Get("Content-Type") != "application/x-protobuf" ||
+		req.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+	compressed, _ := io.ReadAll(req.Body)
+	b, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+	samples, err := decodeWriteRequest(b)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+	r.samples = append(r.samples, samples...)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeWriteRequest returns samples as strings "name{label="value",...} value timestamp"
+func decodeWriteRequest(b []byte) (samples []string, _ error) {
+	fields := func(b []byte, f func(num protowire.Number, typ protowire.Type, v []byte) error) error {
+		for len(b) > 0 {
+			num, typ, n := protowire.ConsumeTag(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			if err := f(num, typ, b[:n]); err != nil {
+				return err
+			}
+			b = b[n:]
+		}
+
+		return nil
+	}
+	err := fields(b, func(_ protowire.Number, _ protowire.Type, ts []byte) error {
+		ts, _ = protowire.ConsumeBytes(ts)
+		var (
+			name   string
+			labels []string
+			value  float64
+			millis int64
+		)
+		err := fields(ts, func(num protowire.Number, _ protowire.Type, v []byte) error {
+			v, _ = protowire.ConsumeBytes(v)
+			switch num {
+			case timeSeriesLabels:
+				var l label
+				err := fields(v, func(num protowire.Number, _ protowire.Type, v []byte) error {
+					s, _ := protowire.ConsumeString(v)
+					if num == labelName {
+						l.name = s
+					} else {
+						l.value = s
+					}
+
+					return nil
+				})
+				if l.name == "__name__" {
+					name = l.value
+				} else {
+					labels = append(labels, l.name+"=\""+l.value+"\"")
+				}
+
+				return err
+			case timeSeriesSamples:
+				return fields(v, func(num protowire.Number, _ protowire.Type, v []byte) error {
+					if num == sampleValue {
+						bits, _ := protowire.ConsumeFixed64(v)
+						value = math.Float64frombits(bits)
+					} else {
+						ts, _ := protowire.ConsumeVarint(v)
+						millis = int64(ts)
+					}
+
+					return nil
+				})
+			default:
+				return errors.New("unknown field")
+			}
+		})
+		if len(labels) > 0 {
+			name += "{" + strings.Join(labels, ",") + "}"
+		}
+		samples = append(samples, name+" "+strconv.FormatFloat(value, 'g', -1, 64)+" "+strconv.FormatInt(millis, 10))
+
+		return err
+	})
+
+	return samples, err
+}
+
+func newRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	ydb := metrics.Config(registry, metrics.WithTimerBuckets([]float64{1})).WithSystem("ydb")
+	ydb.CounterVec("errors", "status").With(map[string]string{"status": "OK"}).Inc()
+	ydb.TimerVec("latency").With(nil).Record(500 * time.Millisecond)
+
+	return registry
+}
+
+func newSender(t *testing.T, url string, queueSize int) *Sender {
+	t.Helper()
+	s, err := NewSender(&Config{
+		URL:          url,
+		Gatherer:     newRegistry(),
+		QueueSize:    queueSize,
+		RetryBackoff: time.Millisecond,
+		Headers:      map[string]string{"Authorization": "Bearer token"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.now = func() time.Time {
+		return time.UnixMilli(1700000000000)
+	}
+
+	return s
+}
+
+var expectedSamples = []string{
+	`ydb_go_sdk_ydb_errors{status="OK"} 1 1700000000000`,
+	`ydb_go_sdk_ydb_latency_bucket{le="1"} 1 1700000000000`,
+	`ydb_go_sdk_ydb_latency_bucket{le="+Inf"} 1 1700000000000`,
+	`ydb_go_sdk_ydb_latency_sum 0.5 1700000000000`,
+	`ydb_go_sdk_ydb_latency_count 1 1700000000000`,
+}
+
+func TestSend(t *testing.T) {
+	r := &receiver{failures: 2}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	if err := newSender(t, server.URL, 0).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.requests != 3 {
+		t.Fatalf("unexpected requests count: %d", r.requests)
+	}
+	if !reflect.DeepEqual(r.samples, expectedSamples) {
+		t.Fatalf("unexpected samples:\n%s\nexpected:\n%s",
+			strings.Join(r.samples, "\n"), strings.Join(expectedSamples, "\n"),
+		)
+	}
+}
+
+func TestQueue(t *testing.T) {
+	r := &receiver{failures: 100}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	s := newSender(t, server.URL, 2)
+	s.config.MaxRetries = -1
+	for i := 0; i < 3; i++ {
+		err := s.Enqueue()
+		if i < 2 && err != nil {
+			t.Fatal(err)
+		}
+		if i == 2 && !errors.Is(err, ErrQueueFull) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := s.Flush(context.Background()); err == nil {
+		t.Fatal("no error")
+	}
+	if len(s.queue) != 2 {
+		t.Fatalf("unexpected queue length: %d", len(s.queue))
+	}
+
+	r.mu.Lock()
+	r.failures = 0
+	r.mu.Unlock()
+	if err := s.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.queue) != 0 {
+		t.Fatalf("unexpected queue length: %d", len(s.queue))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.samples) != 2*len(expectedSamples) {
+		t.Fatalf("unexpected samples count: %d", len(r.samples))
+	}
+}
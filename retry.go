@@ -0,0 +1,242 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	ydbConfig "github.com/ydb-platform/ydb-go-sdk/v3/config"
+	"github.com/ydb-platform/ydb-go-sdk/v3/metrics"
+	"github.com/ydb-platform/ydb-go-sdk/v3/retry"
+	"github.com/ydb-platform/ydb-go-sdk/v3/retry/budget"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+)
+
+type retryLoopKey struct{}
+
+// retryLoop is a state of retry loop, which is stored in context of retry loop and is available
+// for calls of every attempt and for retry budget, which is acquired between attempts
+type retryLoop struct {
+	idempotent string
+
+	m        sync.Mutex
+	retries  int
+	status   string
+	backoff  string
+	failedAt time.Time
+}
+
+// failed remembers status of failed call of current attempt, time of failure and type of backoff
+// which retryer sleeps after attempt failed with this call
+func (l *retryLoop) failed(status, backoff string) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	l.status, l.backoff, l.failedAt = status, backoff, time.Now()
+}
+
+// retry finishes failed attempt before next attempt and returns status of attempt and type and
+// duration of backoff. Status of attempt is status of last failed call of attempt. Attempt may fail
+// without failed call (for example, by error returned from retry operation), such attempt has
+// status "unknown" and has no backoff
+func (l *retryLoop) retry() (status, backoff string, sleep time.Duration) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	l.retries++
+	status, backoff = l.status, l.backoff
+	if status == "" {
+		status = "unknown"
+	} else {
+		sleep = time.Since(l.failedAt)
+	}
+	l.status, l.backoff, l.failedAt = "", "", time.Time{}
+
+	return status, backoff, sleep
+}
+
+// unobserved returns number of retries of loop, which are made without acquiring of retry budget of
+// driver, and whether last attempt is already recorded (retry budget denied attempt after it)
+func (l *retryLoop) unobserved(attempts int) (retries int, recorded bool) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	if l.retries >= attempts {
+		return 0, true
+	}
+
+	return attempts - 1 - l.retries, false
+}
+
+type retryMetrics struct {
+	backoff        metrics.TimerVec
+	failedAttempts metrics.CounterVec
+
+	statuses *boundedLabel
+}
+
+func newRetryMetrics(config metrics.Config) *retryMetrics {
+	config = config.WithSystem("retry")
+
+	return &retryMetrics{
+		backoff:        config.TimerVec("backoff", "backoff"),
+		failedAttempts: config.CounterVec("failed_attempts", "status", "idempotent"),
+		statuses:       newBoundedLabel(config),
+	}
+}
+
+// retryBackoff returns short name of backoff of retry mode: "fast", "slow" or "none"
+func retryBackoff(err error) string {
+	m := retry.Check(err)
+	if !m.MustBackoff() {
+		return "none"
+	}
+
+	return strings.TrimSuffix(m.BackoffType().String(), " backoff")
+}
+
+// withRetries returns driver options with metrics of failed attempts and backoffs of retry loops.
+// Retry trace of ydb-go-sdk has no events of attempts, but retry loop acquires retry budget after
+// sleeping in backoff before every next attempt, so budget of driver is wrapped for observing of
+// ends of failed attempts
+func withRetries(config metrics.Config) ydb.Option {
+	m := newRetryMetrics(config)
+
+	return ydb.MergeOptions(
+		ydb.WithTraceRetry(m.trace(config)),
+		ydb.WithTraceDriver(m.driverTrace()),
+		ydb.With(func(c *ydbConfig.Config) {
+			ydbConfig.WithRetryBudget(&retryBudget{Budget: c.RetryBudget(), metrics: m})(c)
+		}),
+	)
+}
+
+// retryBudget observes failed attempts of retry loops by acquiring of budget before next attempt
+type retryBudget struct {
+	budget.Budget
+
+	metrics *retryMetrics
+}
+
+func (b *retryBudget) Acquire(ctx context.Context) error {
+	if l := b.metrics.loop(&ctx); l != nil {
+		b.metrics.retried(l)
+	}
+
+	return b.Budget.Acquire(ctx)
+}
+
+func (m *retryMetrics) trace(config metrics.Config) (t trace.Retry) {
+	t.OnRetry = func(info trace.RetryLoopStartInfo) func(trace.RetryLoopDoneInfo) {
+		if info.Context == nil || *info.Context == nil || config.Details()&trace.RetryEvents == 0 {
+			return nil
+		}
+		l := &retryLoop{
+			idempotent: strconv.FormatBool(info.Idempotent),
+		}
+		*info.Context = context.WithValue(*info.Context, retryLoopKey{}, l)
+
+		return func(info trace.RetryLoopDoneInfo) {
+			m.done(l, info)
+		}
+	}
+
+	return t
+}
+
+// retried records failed attempt and backoff after it
+func (m *retryMetrics) retried(l *retryLoop) {
+	status, backoff, sleep := l.retry()
+	m.failedAttempts.With(map[string]string{
+		"status":     m.statuses.value(status, status),
+		"idempotent": l.idempotent,
+	}).Inc()
+	if backoff != "" {
+		m.backoff.With(map[string]string{
+			"backoff": backoff,
+		}).Record(sleep)
+	}
+}
+
+// done records last attempt of loop if it failed. Retries of loops with own retry budget (for example,
+// retry budget passed to query.Client.Do) are not observed by budget of driver, so such attempts
+// are recorded with status "unknown" on end of loop
+func (m *retryMetrics) done(l *retryLoop, info trace.RetryLoopDoneInfo) {
+	retries, recorded := l.unobserved(info.Attempts)
+	for i := 0; i < retries; i++ {
+		m.failedAttempts.With(map[string]string{
+			"status":     m.statuses.value("unknown", "unknown"),
+			"idempotent": l.idempotent,
+		}).Inc()
+	}
+	if info.Error != nil && !recorded {
+		status := errorBrief(info.Error)
+		m.failedAttempts.With(map[string]string{
+			"status":     m.statuses.value(status, status),
+			"idempotent": l.idempotent,
+		}).Inc()
+	}
+}
+
+// loop returns state of retry loop from context of call
+func (m *retryMetrics) loop(ctx *context.Context) *retryLoop {
+	if ctx == nil || *ctx == nil {
+		return nil
+	}
+	l, _ := (*ctx).Value(retryLoopKey{}).(*retryLoop)
+
+	return l
+}
+
+// onCall observes call made inside retry loop and returns function which observes result of call.
+// Retry loop puts its state into context of loop, so calls are bound to loop by context of call.
+// Failed call is remembered as cause of failure of current attempt
+func (m *retryMetrics) onCall(ctx *context.Context) func(err error) {
+	l := m.loop(ctx)
+	if l == nil {
+		return nil
+	}
+
+	return func(err error) {
+		if err == nil || errors.Is(err, io.EOF) {
+			return
+		}
+		l.failed(errorBrief(err), retryBackoff(err))
+	}
+}
+
+func (m *retryMetrics) driverTrace() (t trace.Driver) {
+	t.OnConnInvoke = func(info trace.DriverConnInvokeStartInfo) func(trace.DriverConnInvokeDoneInfo) {
+		if done := m.onCall(info.Context); done != nil {
+			return func(info trace.DriverConnInvokeDoneInfo) {
+				done(info.Error)
+			}
+		}
+
+		return nil
+	}
+	t.OnConnNewStream = func(info trace.DriverConnNewStreamStartInfo) func(trace.DriverConnNewStreamDoneInfo) {
+		if done := m.onCall(info.Context); done != nil {
+			return func(info trace.DriverConnNewStreamDoneInfo) {
+				done(info.Error)
+			}
+		}
+
+		return nil
+	}
+	t.OnConnStreamRecvMsg = func(
+		info trace.DriverConnStreamRecvMsgStartInfo,
+	) func(trace.DriverConnStreamRecvMsgDoneInfo) {
+		if done := m.onCall(info.Context); done != nil {
+			return func(info trace.DriverConnStreamRecvMsgDoneInfo) {
+				done(info.Error)
+			}
+		}
+
+		return nil
+	}
+
+	return t
+}
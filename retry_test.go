@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ydb-platform/ydb-go-sdk/v3/retry"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type testBackoff time.Duration
+
+func (b testBackoff) Delay(int) time.Duration { return time.Duration(b) }
+
+type testBudget struct {
+	err error
+}
+
+func (b testBudget) Acquire(context.Context) error { return b.err }
+
+func TestRetryAttempts(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	config := Config(registry).WithSystem("ydb")
+	m := newRetryMetrics(config)
+	r, d := m.trace(config), m.driverTrace()
+
+	invoke := func(ctx context.Context, err error) {
+		if done := d.OnConnInvoke(trace.DriverConnInvokeStartInfo{Context: &ctx}); done != nil {
+			done(trace.DriverConnInvokeDoneInfo{Error: err})
+		}
+	}
+	failed := retry.RetryableError(&net.OpError{Op: "dial", Err: errors.New("test")},
+		retry.WithBackoff(retry.TypeFastBackoff),
+	)
+	attempt := 0
+	err := retry.Retry(context.Background(), func(ctx context.Context) error {
+		attempt++
+		switch attempt {
+		case 1:
+			// attempt failed by failed call
+			invoke(ctx, context.DeadlineExceeded)
+			invoke(ctx, failed)
+
+			return failed
+		case 2:
+			// attempt failed without failed call, end of stream is not a failure
+			if done := d.OnConnStreamRecvMsg(trace.DriverConnStreamRecvMsgStartInfo{Context: &ctx}); done != nil {
+				done(trace.DriverConnStreamRecvMsgDoneInfo{Error: io.EOF})
+			}
+
+			return failed
+		default:
+			invoke(ctx, nil)
+
+			return nil
+		}
+	},
+		retry.WithIdempotent(true),
+		retry.WithTrace(&r),
+		retry.WithBudget(&retryBudget{Budget: testBudget{}, metrics: m}),
+		retry.WithFastBackoff(testBackoff(10*time.Millisecond)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// call outside of retry loop
+	invoke(context.Background(), context.Canceled)
+
+	idempotent := func(status string) map[string]string {
+		return map[string]string{"status": status, "idempotent": "true"}
+	}
+	assertValue(t, registry, "ydb_go_sdk_ydb_retry_failed_attempts", nil, 2)
+	assertValue(t, registry, "ydb_go_sdk_ydb_retry_failed_attempts", idempotent("network/dial"), 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_retry_failed_attempts", idempotent("unknown"), 1)
+	// backoff is recorded only after attempt failed by failed call
+	assertValue(t, registry, "ydb_go_sdk_ydb_retry_backoff", map[string]string{"backoff": "fast"}, 1)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range families {
+		if f.GetName() != "ydb_go_sdk_ydb_retry_backoff" {
+			continue
+		}
+		if sleep := f.GetMetric()[0].GetHistogram().GetSampleSum(); sleep < 0.01 || sleep > 1 {
+			t.Errorf("unexpected backoff: %v", sleep)
+		}
+	}
+}
+
+func TestRetryAttemptsWithoutBudget(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	config := Config(registry).WithSystem("ydb")
+	m := newRetryMetrics(config)
+	r := m.trace(config)
+
+	failed := retry.RetryableError(errors.New("test"), retry.WithBackoff(retry.TypeNoBackoff))
+	err := retry.Retry(context.Background(), func(ctx context.Context) error {
+		return failed
+	},
+		retry.WithTrace(&r),
+		retry.WithBudget(testBudget{err: errors.New("no quota")}),
+	)
+	if err == nil {
+		t.Fatal("no error")
+	}
+	// retry is denied by budget, which is not wrapped, so attempts are recorded on end of loop
+	assertValue(t, registry, "ydb_go_sdk_ydb_retry_failed_attempts", nil, 1)
+
+	err = retry.Retry(context.Background(), func(ctx context.Context) error {
+		return failed
+	},
+		retry.WithTrace(&r),
+		retry.WithBudget(&retryBudget{Budget: testBudget{err: errors.New("no quota")}, metrics: m}),
+	)
+	if err == nil {
+		t.Fatal("no error")
+	}
+	// attempt denied by wrapped budget is recorded once
+	assertValue(t, registry, "ydb_go_sdk_ydb_retry_failed_attempts", nil, 2)
+}
+
+func TestRetryBackoffType(t *testing.T) {
+	if backoff := retryBackoff(status.Error(codes.Unavailable, "test")); backoff != "none" {
+		t.Errorf("unexpected backoff of not ydb error: %q", backoff)
+	}
+	err := retry.RetryableError(errors.New("test"), retry.WithBackoff(retry.TypeSlowBackoff))
+	if backoff := retryBackoff(err); backoff != "slow" {
+		t.Errorf("unexpected backoff of retryable error: %q", backoff)
+	}
+}
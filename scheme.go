@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/ydb-platform/ydb-go-genproto/Ydb_Table_V1"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/metrics"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+)
+
+// schemeTableMethods are methods of table service which change scheme of database
+var schemeTableMethods = map[string]string{
+	Ydb_Table_V1.TableService_CreateTable_FullMethodName:  "create_table",
+	Ydb_Table_V1.TableService_AlterTable_FullMethodName:   "alter_table",
+	Ydb_Table_V1.TableService_DropTable_FullMethodName:    "drop_table",
+	Ydb_Table_V1.TableService_CopyTable_FullMethodName:    "copy_table",
+	Ydb_Table_V1.TableService_CopyTables_FullMethodName:   "copy_tables",
+	Ydb_Table_V1.TableService_RenameTables_FullMethodName: "rename_tables",
+}
+
+type schemeMetrics struct {
+	latency metrics.TimerVec
+	errors  metrics.CounterVec
+}
+
+func newSchemeMetrics(config metrics.Config) *schemeMetrics {
+	config = config.WithSystem("scheme")
+
+	return &schemeMetrics{
+		latency: config.TimerVec("latency", "method", "status"),
+		errors:  config.CounterVec("errors", "method", "status"),
+	}
+}
+
+// scheme returns driver options with metrics of scheme operations of scheme client and table sessions
+func scheme(config metrics.Config) ydb.Option {
+	m := newSchemeMetrics(config)
+
+	return ydb.MergeOptions(
+		ydb.WithTraceScheme(m.trace(config)),
+		ydb.WithTraceDriver(m.tableTrace(config)),
+	)
+}
+
+// observe returns function which records latency and error of operation started now
+func (m *schemeMetrics) observe(config metrics.Config, method string) func(err error) {
+	if config.Details()&trace.SchemeEvents == 0 {
+		return nil
+	}
+	start := time.Now()
+
+	return func(err error) {
+		labels := map[string]string{
+			"method": method,
+			"status": errorBrief(err),
+		}
+		m.latency.With(labels).Record(time.Since(start))
+		if err != nil {
+			m.errors.With(labels).Inc()
+		}
+	}
+}
+
+func (m *schemeMetrics) trace(config metrics.Config) (t trace.Scheme) {
+	t.OnListDirectory = func(info trace.SchemeListDirectoryStartInfo) func(trace.SchemeListDirectoryDoneInfo) {
+		if done := m.observe(config, "list_directory"); done != nil {
+			return func(info trace.SchemeListDirectoryDoneInfo) {
+				done(info.Error)
+			}
+		}
+
+		return nil
+	}
+	t.OnDescribePath = func(info trace.SchemeDescribePathStartInfo) func(trace.SchemeDescribePathDoneInfo) {
+		if done := m.observe(config, "describe_path"); done != nil {
+			return func(info trace.SchemeDescribePathDoneInfo) {
+				done(info.Error)
+			}
+		}
+
+		return nil
+	}
+	t.OnMakeDirectory = func(info trace.SchemeMakeDirectoryStartInfo) func(trace.SchemeMakeDirectoryDoneInfo) {
+		if done := m.observe(config, "make_directory"); done != nil {
+			return func(info trace.SchemeMakeDirectoryDoneInfo) {
+				done(info.Error)
+			}
+		}
+
+		return nil
+	}
+	t.OnRemoveDirectory = func(info trace.SchemeRemoveDirectoryStartInfo) func(trace.SchemeRemoveDirectoryDoneInfo) {
+		if done := m.observe(config, "remove_directory"); done != nil {
+			return func(info trace.SchemeRemoveDirectoryDoneInfo) {
+				done(info.Error)
+			}
+		}
+
+		return nil
+	}
+	t.OnModifyPermissions = func(
+		info trace.SchemeModifyPermissionsStartInfo,
+	) func(trace.SchemeModifyPermissionsDoneInfo) {
+		if done := m.observe(config, "modify_permissions"); done != nil {
+			return func(info trace.SchemeModifyPermissionsDoneInfo) {
+				done(info.Error)
+			}
+		}
+
+		return nil
+	}
+
+	return t
+}
+
+func (m *schemeMetrics) tableTrace(config metrics.Config) (t trace.Driver) {
+	t.OnConnInvoke = func(info trace.DriverConnInvokeStartInfo) func(trace.DriverConnInvokeDoneInfo) {
+		method, has := schemeTableMethods[string(info.Method)]
+		if !has {
+			return nil
+		}
+		if done := m.observe(config, method); done != nil {
+			return func(info trace.DriverConnInvokeDoneInfo) {
+				done(info.Error)
+			}
+		}
+
+		return nil
+	}
+
+	return t
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ydb-platform/ydb-go-genproto/Ydb_Table_V1"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+)
+
+func TestScheme(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	config := Config(registry).WithSystem("ydb")
+	m := newSchemeMetrics(config)
+	s, d := m.trace(config), m.tableTrace(config)
+
+	s.OnMakeDirectory(trace.SchemeMakeDirectoryStartInfo{})(trace.SchemeMakeDirectoryDoneInfo{})
+	s.OnDescribePath(trace.SchemeDescribePathStartInfo{})(trace.SchemeDescribePathDoneInfo{
+		Error: context.DeadlineExceeded,
+	})
+	invoke := func(method string, err error) {
+		if done := d.OnConnInvoke(trace.DriverConnInvokeStartInfo{Method: trace.Method(method)}); done != nil {
+			done(trace.DriverConnInvokeDoneInfo{Error: err})
+		}
+	}
+	invoke(Ydb_Table_V1.TableService_DropTable_FullMethodName, nil)
+	invoke(Ydb_Table_V1.TableService_CreateTable_FullMethodName, nil)
+	invoke(Ydb_Table_V1.TableService_CreateTable_FullMethodName, context.Canceled)
+	invoke(Ydb_Table_V1.TableService_ExecuteDataQuery_FullMethodName, nil)
+
+	for _, c := range []struct {
+		method, status string
+		expected       float64
+	}{
+		{method: "make_directory", status: "OK", expected: 1},
+		{method: "describe_path", status: "context/DeadlineExceeded", expected: 1},
+		{method: "drop_table", status: "OK", expected: 1},
+		{method: "create_table", status: "OK", expected: 1},
+		{method: "create_table", status: "context/Canceled", expected: 1},
+	} {
+		assertValue(t, registry, "ydb_go_sdk_ydb_scheme_latency",
+			map[string]string{"method": c.method, "status": c.status}, c.expected)
+	}
+	assertValue(t, registry, "ydb_go_sdk_ydb_scheme_errors",
+		map[string]string{"method": "create_table", "status": "context/Canceled"}, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_scheme_errors",
+		map[string]string{"method": "describe_path", "status": "context/DeadlineExceeded"}, 1)
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/metrics"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+)
+
+type scriptingMetrics struct {
+	latency metrics.TimerVec
+	errors  metrics.CounterVec
+}
+
+func newScriptingMetrics(config metrics.Config) *scriptingMetrics {
+	config = config.WithSystem("scripting")
+
+	return &scriptingMetrics{
+		latency: config.TimerVec("latency", "method", "status"),
+		errors:  config.CounterVec("errors", "method", "status"),
+	}
+}
+
+// scripting returns driver option with metrics of scripting operations
+func scripting(config metrics.Config) ydb.Option {
+	m := newScriptingMetrics(config)
+
+	return ydb.WithTraceScripting(m.trace(config))
+}
+
+// observe returns function which records latency and error of operation started now
+func (m *scriptingMetrics) observe(config metrics.Config, method string) func(err error) {
+	if config.Details()&trace.ScriptingEvents == 0 {
+		return nil
+	}
+	start := time.Now()
+
+	return func(err error) {
+		labels := map[string]string{
+			"method": method,
+			"status": errorBrief(err),
+		}
+		m.latency.With(labels).Record(time.Since(start))
+		if err != nil {
+			m.errors.With(labels).Inc()
+		}
+	}
+}
+
+func (m *scriptingMetrics) trace(config metrics.Config) (t trace.Scripting) {
+	t.OnExecute = func(info trace.ScriptingExecuteStartInfo) func(trace.ScriptingExecuteDoneInfo) {
+		if done := m.observe(config, "execute"); done != nil {
+			return func(info trace.ScriptingExecuteDoneInfo) {
+				done(info.Error)
+			}
+		}
+
+		return nil
+	}
+	t.OnStreamExecute = func(
+		info trace.ScriptingStreamExecuteStartInfo,
+	) func(trace.ScriptingStreamExecuteIntermediateInfo) func(trace.ScriptingStreamExecuteDoneInfo) {
+		if done := m.observe(config, "stream_execute"); done != nil {
+			return func(info trace.ScriptingStreamExecuteIntermediateInfo) func(trace.ScriptingStreamExecuteDoneInfo) {
+				return func(info trace.ScriptingStreamExecuteDoneInfo) {
+					done(info.Error)
+				}
+			}
+		}
+
+		return nil
+	}
+	t.OnExplain = func(info trace.ScriptingExplainStartInfo) func(trace.ScriptingExplainDoneInfo) {
+		if done := m.observe(config, "explain"); done != nil {
+			return func(info trace.ScriptingExplainDoneInfo) {
+				done(info.Error)
+			}
+		}
+
+		return nil
+	}
+
+	return t
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+)
+
+func TestScripting(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	config := Config(registry).WithSystem("ydb")
+	s := newScriptingMetrics(config).trace(config)
+
+	s.OnExecute(trace.ScriptingExecuteStartInfo{})(trace.ScriptingExecuteDoneInfo{})
+	s.OnExecute(trace.ScriptingExecuteStartInfo{})(trace.ScriptingExecuteDoneInfo{Error: context.Canceled})
+	s.OnStreamExecute(trace.ScriptingStreamExecuteStartInfo{})(trace.ScriptingStreamExecuteIntermediateInfo{})(
+		trace.ScriptingStreamExecuteDoneInfo{},
+	)
+	s.OnExplain(trace.ScriptingExplainStartInfo{})(trace.ScriptingExplainDoneInfo{})
+
+	for _, c := range []struct {
+		method, status string
+	}{
+		{method: "execute", status: "OK"},
+		{method: "execute", status: "context/Canceled"},
+		{method: "stream_execute", status: "OK"},
+		{method: "explain", status: "OK"},
+	} {
+		assertValue(t, registry, "ydb_go_sdk_ydb_scripting_latency",
+			map[string]string{"method": c.method, "status": c.status}, 1)
+	}
+	assertValue(t, registry, "ydb_go_sdk_ydb_scripting_errors",
+		map[string]string{"method": "execute", "status": "context/Canceled"}, 1)
+}
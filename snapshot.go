@@ -0,0 +1,154 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Snapshot is a JSON-serializable view of all metrics owned by prometheus adapter
+type Snapshot struct {
+	Metrics []MetricSnapshot `json:"metrics"`
+}
+
+// MetricSnapshot is a view of single metric vector.
+// Path is a namespace split by subsystems with name of metric
+type MetricSnapshot struct {
+	Name   string           `json:"name"`
+	Path   []string         `json:"path"`
+	Type   string           `json:"type"`
+	Series []SeriesSnapshot `json:"series"`
+}
+
+// SeriesSnapshot is a view of single series of metric vector.
+// Value filled for counters and gauges, Count, Sum and Buckets - for timers and histograms
+type SeriesSnapshot struct {
+	Labels  map[string]string `json:"labels,omitempty"`
+	Value   *float64          `json:"value,omitempty"`
+	Count   *uint64           `json:"count,omitempty"`
+	Sum     *float64          `json:"sum,omitempty"`
+	Buckets []BucketSnapshot  `json:"buckets,omitempty"`
+}
+
+// BucketSnapshot is a cumulative count of observations less or equal UpperBound
+type BucketSnapshot struct {
+	UpperBound float64 `json:"le"`
+	Count      uint64  `json:"count"`
+}
+
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeTimer     = "timer"
+	typeHistogram = "histogram"
+)
+
+// Snapshot returns current values of all metrics created by config and its subsystem configs
+func (c *config) Snapshot() Snapshot {
+	c.vectors.m.Lock()
+	defer c.vectors.m.Unlock()
+
+	var s Snapshot
+	for key, cnt := range c.vectors.counters {
+		s.Metrics = append(s.Metrics, newMetricSnapshot(key, typeCounter, cnt.path, cnt.c))
+	}
+	for key, g := range c.vectors.gauges {
+		s.Metrics = append(s.Metrics, newMetricSnapshot(key, typeGauge, g.path, g.g))
+	}
+	for key, t := range c.vectors.timers {
+		s.Metrics = append(s.Metrics, newMetricSnapshot(key, typeTimer, t.path, t.t))
+	}
+	for key, h := range c.vectors.histograms {
+		s.Metrics = append(s.Metrics, newMetricSnapshot(key, typeHistogram, h.path, h.h))
+	}
+	// different paths can be joined into same name (for example, subsystems "a_b" and "c" with
+	// subsystems "a" and "b_c"), so path is a part of sort key for stable order of metrics
+	sort.Slice(s.Metrics, func(i, j int) bool {
+		if s.Metrics[i].Name != s.Metrics[j].Name {
+			return s.Metrics[i].Name < s.Metrics[j].Name
+		}
+		if s.Metrics[i].Type != s.Metrics[j].Type {
+			return s.Metrics[i].Type < s.Metrics[j].Type
+		}
+
+		return strings.Join(s.Metrics[i].Path, "\xff") < strings.Join(s.Metrics[j].Path, "\xff")
+	})
+
+	return s
+}
+
+func newMetricSnapshot(key metricKey, typ string, path []string, collector prometheus.Collector) MetricSnapshot {
+	s := MetricSnapshot{
+		Name:   prometheus.BuildFQName(key.Namespace, key.Subsystem, key.Name),
+		Path:   path,
+		Type:   typ,
+		Series: []SeriesSnapshot{},
+	}
+	series := collect(collector)
+	// vectors collect series in random order
+	sort.Slice(series, func(i, j int) bool {
+		return seriesKey(series[i]) < seriesKey(series[j])
+	})
+	for _, m := range series {
+		s.Series = append(s.Series, newSeriesSnapshot(m))
+	}
+
+	return s
+}
+
+// seriesKey returns labels of series as string. Labels of written metric are sorted by name
+func seriesKey(m *dto.Metric) string {
+	pairs := make([]string, 0, len(m.GetLabel()))
+	for _, l := range m.GetLabel() {
+		pairs = append(pairs, l.GetName()+"="+l.GetValue())
+	}
+
+	return strings.Join(pairs, "\xff")
+}
+
+func newSeriesSnapshot(m *dto.Metric) (s SeriesSnapshot) {
+	if len(m.GetLabel()) > 0 {
+		s.Labels = make(map[string]string, len(m.GetLabel()))
+		for _, l := range m.GetLabel() {
+			s.Labels[l.GetName()] = l.GetValue()
+		}
+	}
+	switch {
+	case m.GetCounter() != nil:
+		s.Value = m.GetCounter().Value
+	case m.GetGauge() != nil:
+		s.Value = m.GetGauge().Value
+	case m.GetHistogram() != nil:
+		s.Count = m.GetHistogram().SampleCount
+		s.Sum = m.GetHistogram().SampleSum
+		for _, b := range m.GetHistogram().GetBucket() {
+			s.Buckets = append(s.Buckets, BucketSnapshot{
+				UpperBound: b.GetUpperBound(),
+				Count:      b.GetCumulativeCount(),
+			})
+		}
+	}
+
+	return s
+}
+
+// collect returns written metrics of collector
+func collect(collector prometheus.Collector) []*dto.Metric {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		collector.Collect(ch)
+	}()
+	var metrics []*dto.Metric
+	for m := range ch {
+		pb := &dto.Metric{}
+		if err := m.Write(pb); err != nil {
+			continue
+		}
+		metrics = append(metrics, pb)
+	}
+
+	return metrics
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestSnapshot(t *testing.T) {
+	c := Config(prometheus.NewRegistry(), WithTimerBuckets([]float64{1}))
+	ydb := c.WithSystem("ydb")
+	ydb.CounterVec("errors", "status").With(map[string]string{"status": "OK"}).Inc()
+	ydb.GaugeVec("conns").With(nil).Set(2)
+	ydb.TimerVec("latency").With(nil).Record(500 * time.Millisecond)
+	ydb.WithSystem("retry").HistogramVec("attempts", []float64{1, 2}).With(nil).Record(2)
+
+	b, err := json.Marshal(c.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	const expected = `{"metrics":[` +
+		`{"name":"ydb_go_sdk_ydb_conns","path":["ydb_go_sdk","ydb","conns"],"type":"gauge","series":[{"value":2}]},` +
+		`{"name":"ydb_go_sdk_ydb_errors","path":["ydb_go_sdk","ydb","errors"],"type":"counter","series":[{"labels":{"status":"OK"},"value":1}]},` +
+		`{"name":"ydb_go_sdk_ydb_latency","path":["ydb_go_sdk","ydb","latency"],"type":"timer","series":[` +
+		`{"count":1,"sum":0.5,"buckets":[{"le":1,"count":1}]}]},` +
+		`{"name":"ydb_go_sdk_ydb_retry_attempts","path":["ydb_go_sdk","ydb","retry","attempts"],"type":"histogram","series":[` +
+		`{"count":1,"sum":2,"buckets":[{"le":1,"count":0},{"le":2,"count":1}]}]}` +
+		`]}`
+	if string(b) != expected {
+		t.Fatalf("unexpected snapshot:\n%s\nexpected:\n%s", b, expected)
+	}
+}
+
+func TestSnapshotOrder(t *testing.T) {
+	c := Config(prometheus.NewRegistry())
+	ydb := c.WithSystem("ydb")
+	// paths ydb/a_b/c and ydb/a/b_c have same name
+	ydb.WithSystem("a_b").CounterVec("c", "status").With(map[string]string{"status": "OK"}).Inc()
+	ydb.WithSystem("a").CounterVec("b_c", "status").With(map[string]string{"status": "OK"}).Inc()
+	for i := 0; i < 20; i++ {
+		ydb.CounterVec("errors", "status").With(map[string]string{"status": fmt.Sprintf("s%d", i)}).Inc()
+	}
+
+	first, err := json.Marshal(c.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		b, err := json.Marshal(c.Snapshot())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != string(first) {
+			t.Fatalf("snapshots differ:\n%s\n%s", first, b)
+		}
+	}
+}
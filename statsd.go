@@ -0,0 +1,250 @@
+package metrics
+
+import (
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/metrics"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+)
+
+var (
+	_ metrics.Config = (*statsdConfig)(nil)
+)
+
+// statsdConfig is an implementation of metrics.Config which sends metrics
+// to StatsD server over UDP in DogStatsD format (labels sent as tags)
+type statsdConfig struct {
+	detailer   trace.Detailer
+	separator  string
+	namespace  string
+	labelLimit int
+	conn       io.WriteCloser
+	gauges     *statsdGauges
+}
+
+// statsdGauges keeps values of gauges by series. DogStatsD treats signed values as absolute,
+// so gauges are changed locally and always sent as absolute value
+type statsdGauges struct {
+	m      sync.Mutex
+	values map[string]float64
+}
+
+// StatsdConfig returns metrics.Config which sends metrics to StatsD (DogStatsD) server by address.
+// Options are same as for Config: WithNamespace, WithSeparator, WithDetailer and WithLabelValuesLimit are applied,
+// buckets and registerers options are ignored.
+// Subsystems and metric names are joined with separator
+func StatsdConfig(address string, opts ...option) (*statsdConfig, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	return newStatsdConfig(conn, opts...), nil
+}
+
+func newStatsdConfig(conn io.WriteCloser, opts ...option) *statsdConfig {
+	c := &config{
+		detailer:   trace.DetailsAll,
+		namespace:  defaultNamespace,
+		separator:  defaultSeparator,
+		labelLimit: defaultLabelValuesLimit,
+	}
+	for _, o := range opts {
+		o(c)
+	}
+
+	return &statsdConfig{
+		detailer:   c.detailer,
+		separator:  c.separator,
+		namespace:  c.namespace,
+		labelLimit: c.labelLimit,
+		conn:       conn,
+		gauges:     &statsdGauges{values: make(map[string]float64)},
+	}
+}
+
+// WithStatsdTraces is same as WithTraces, but sends metrics to StatsD (DogStatsD) server with config
+// created by StatsdConfig. Caller owns config and closes it after closing of driver
+func WithStatsdTraces(c *statsdConfig) ydb.Option {
+	return withTraces(c)
+}
+
+// Close closes connection to StatsD server
+func (c *statsdConfig) Close() error {
+	return c.conn.Close()
+}
+
+func (c *statsdConfig) join(a, b string) string {
+	if a == "" {
+		return b
+	}
+	if b == "" {
+		return ""
+	}
+
+	return strings.Join([]string{a, b}, c.separator)
+}
+
+func (c *statsdConfig) WithSystem(subsystem string) metrics.Config {
+	return &statsdConfig{
+		detailer:   c.detailer,
+		separator:  c.separator,
+		namespace:  c.join(c.namespace, subsystem),
+		labelLimit: c.labelLimit,
+		conn:       c.conn,
+		gauges:     c.gauges,
+	}
+}
+
+func (c *statsdConfig) Details() trace.Details {
+	return c.detailer.Details()
+}
+
+func (c *statsdConfig) vec(name string, labelNames []string) *statsdVec {
+	return &statsdVec{
+		name:       c.join(c.namespace, name),
+		labelNames: labelNames,
+		conn:       c.conn,
+	}
+}
+
+func (c *statsdConfig) CounterVec(name string, labelNames ...string) metrics.CounterVec {
+	return &statsdCounterVec{c.vec(name, labelNames)}
+}
+
+func (c *statsdConfig) GaugeVec(name string, labelNames ...string) metrics.GaugeVec {
+	return &statsdGaugeVec{c.vec(name, labelNames), c.gauges}
+}
+
+func (c *statsdConfig) TimerVec(name string, labelNames ...string) metrics.TimerVec {
+	return &statsdTimerVec{c.vec(name, labelNames)}
+}
+
+func (c *statsdConfig) HistogramVec(name string, _ []float64, labelNames ...string) metrics.HistogramVec {
+	return &statsdHistogramVec{c.vec(name, labelNames)}
+}
+
+type statsdVec struct {
+	name       string
+	labelNames []string
+	conn       io.Writer
+}
+
+// with returns metric with tags in DogStatsD format
+func (v *statsdVec) with(labels map[string]string) *statsdMetric {
+	m := &statsdMetric{
+		name: v.name,
+		conn: v.conn,
+	}
+	if len(v.labelNames) > 0 {
+		tags := make([]string, 0, len(v.labelNames))
+		for _, name := range v.labelNames {
+			tags = append(tags, name+":"+strings.NewReplacer(",", "_", "|", "_").Replace(labels[name]))
+		}
+		m.tags = "|#" + strings.Join(tags, ",")
+	}
+
+	return m
+}
+
+type statsdMetric struct {
+	name string
+	tags string
+	conn io.Writer
+}
+
+// send writes single metric in packet. Errors are ignored because UDP is fire-and-forget
+func (m *statsdMetric) send(value, typ string) {
+	_, _ = io.WriteString(m.conn, m.name+":"+value+"|"+typ+m.tags)
+}
+
+type statsdCounterVec struct {
+	v *statsdVec
+}
+
+func (c *statsdCounterVec) With(labels map[string]string) metrics.Counter {
+	return &statsdCounter{c.v.with(labels)}
+}
+
+type statsdCounter struct {
+	m *statsdMetric
+}
+
+func (c *statsdCounter) Inc() {
+	c.m.send("1", "c")
+}
+
+type statsdGaugeVec struct {
+	v      *statsdVec
+	gauges *statsdGauges
+}
+
+func (g *statsdGaugeVec) With(labels map[string]string) metrics.Gauge {
+	return &statsdGauge{g.v.with(labels), g.gauges}
+}
+
+type statsdGauge struct {
+	m      *statsdMetric
+	gauges *statsdGauges
+}
+
+func (g *statsdGauge) Add(delta float64) {
+	g.update(func(value float64) float64 {
+		return value + delta
+	})
+}
+
+func (g *statsdGauge) Set(value float64) {
+	g.update(func(float64) float64 {
+		return value
+	})
+}
+
+// update sends value under lock, so server receives values of series in order of changes
+func (g *statsdGauge) update(f func(value float64) float64) {
+	g.gauges.m.Lock()
+	defer g.gauges.m.Unlock()
+
+	key := g.m.name + g.m.tags
+	value := f(g.gauges.values[key])
+	g.gauges.values[key] = value
+	g.m.send(formatFloat(value), "g")
+}
+
+type statsdTimerVec struct {
+	v *statsdVec
+}
+
+func (t *statsdTimerVec) With(labels map[string]string) metrics.Timer {
+	return &statsdTimer{t.v.with(labels)}
+}
+
+type statsdTimer struct {
+	m *statsdMetric
+}
+
+func (t *statsdTimer) Record(d time.Duration) {
+	t.m.send(strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64), "ms")
+}
+
+type statsdHistogramVec struct {
+	v *statsdVec
+}
+
+func (h *statsdHistogramVec) With(labels map[string]string) metrics.Histogram {
+	return &statsdHistogram{h.v.with(labels)}
+}
+
+type statsdHistogram struct {
+	m *statsdMetric
+}
+
+func (h *statsdHistogram) Record(v float64) {
+	h.m.send(formatFloat(v), "h")
+}
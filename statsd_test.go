@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	ydbMetrics "github.com/ydb-platform/ydb-go-sdk/v3/metrics"
+
+	"github.com/ydb-platform/ydb-go-sdk-prometheus/v2/metricstest"
+)
+
+func listenUDP(t *testing.T) net.PacketConn {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return conn
+}
+
+func TestStatsdConformance(t *testing.T) {
+	metricstest.Run(t, func(t *testing.T) ydbMetrics.Config {
+		c, err := StatsdConfig(listenUDP(t).LocalAddr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			_ = c.Close()
+		})
+
+		return c
+	})
+}
+
+func TestStatsd(t *testing.T) {
+	server := listenUDP(t)
+	c, err := StatsdConfig(server.LocalAddr().String(), WithNamespace("app"), WithSeparator("."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ydb := c.WithSystem("ydb")
+	ydb.WithSystem("retry").CounterVec("errors", "status", "final").
+		With(map[string]string{"status": "OK", "final": "true"}).Inc()
+	ydb.GaugeVec("conns", "endpoint").With(map[string]string{"endpoint": "localhost:2136"}).Set(-1)
+	ydb.GaugeVec("conns", "endpoint").With(map[string]string{"endpoint": "localhost:2136"}).Add(2)
+	ydb.GaugeVec("conns", "endpoint").With(map[string]string{"endpoint": "localhost:2135"}).Add(-1)
+	c.WithSystem("ydb").GaugeVec("conns", "endpoint").With(map[string]string{"endpoint": "localhost:2136"}).Add(0.5)
+	ydb.TimerVec("latency").With(nil).Record(1500 * time.Microsecond)
+	ydb.HistogramVec("attempts", []float64{1, 2}).With(nil).Record(3)
+
+	buf := make([]byte, 1024)
+	for _, expected := range []string{
+		"app.ydb.retry.errors:1|c|#status:OK,final:true",
+		"app.ydb.conns:-1|g|#endpoint:localhost:2136",
+		"app.ydb.conns:1|g|#endpoint:localhost:2136",
+		"app.ydb.conns:-1|g|#endpoint:localhost:2135",
+		"app.ydb.conns:1.5|g|#endpoint:localhost:2136",
+		"app.ydb.latency:1.5|ms",
+		"app.ydb.attempts:3|h",
+	} {
+		_ = server.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := server.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(buf[:n]); got != expected {
+			t.Fatalf("unexpected packet %q, expected %q", got, expected)
+		}
+	}
+}
+
+func TestStatsdGaugeConcurrent(t *testing.T) {
+	const (
+		goroutines = 4
+		iterations = 25
+	)
+	server := listenUDP(t)
+	c, err := StatsdConfig(server.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// packets are read while they are sent, so receive buffer of server is never overflowed
+	received := make(chan map[string]bool)
+	go func() {
+		packets := make(map[string]bool, goroutines*iterations)
+		defer func() {
+			received <- packets
+		}()
+		buf := make([]byte, 1024)
+		for len(packets) < goroutines*iterations {
+			_ = server.SetReadDeadline(time.Now().Add(5 * time.Second))
+			n, _, err := server.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			packets[string(buf[:n])] = true
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				c.GaugeVec("gauge").With(nil).Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	packets := <-received
+	// each change is sent as absolute value, so every value from 1 to total is received once
+	for i := 1; i <= goroutines*iterations; i++ {
+		if expected := fmt.Sprintf("ydb_go_sdk_gauge:%d|g", i); !packets[expected] {
+			t.Fatalf("packet %q not received, received %v", expected, packets)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/metrics"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+)
+
+// tableSession is a tracked state of table session
+type tableSession struct {
+	created         time.Time
+	uses            int
+	idle            bool
+	keepAliveFailed bool
+	badSession      bool
+}
+
+// table makes table trace with metrics of waiting for session from pool and lifetime of sessions
+//
+//nolint:funlen
+func table(config metrics.Config) (t trace.Table) {
+	config = config.WithSystem("table")
+	getWait := config.WithSystem("pool").TimerVec("get_wait", "status")
+	session := config.WithSystem("session")
+	age := session.TimerVec("age")
+	uses := session.HistogramVec("uses", []float64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000, 10000})
+	closes := session.CounterVec("closes", "reason")
+
+	var (
+		mu       sync.Mutex
+		sessions = make(map[string]*tableSession)
+		closing  bool
+	)
+	withSession := func(id string, f func(s *tableSession)) {
+		mu.Lock()
+		defer mu.Unlock()
+		if s, has := sessions[id]; has {
+			f(s)
+		}
+	}
+	onSessionError := func(id string, err error) {
+		if ydb.IsOperationError(err, Ydb.StatusIds_BAD_SESSION, Ydb.StatusIds_SESSION_EXPIRED) {
+			withSession(id, func(s *tableSession) {
+				s.badSession = true
+			})
+		}
+	}
+
+	t.OnClose = func(info trace.TableCloseStartInfo) func(trace.TableCloseDoneInfo) {
+		mu.Lock()
+		defer mu.Unlock()
+		closing = true
+
+		return nil
+	}
+	t.OnSessionNew = func(info trace.TableSessionNewStartInfo) func(trace.TableSessionNewDoneInfo) {
+		return func(info trace.TableSessionNewDoneInfo) {
+			if info.Error == nil && config.Details()&trace.TableSessionLifeCycleEvents != 0 {
+				mu.Lock()
+				defer mu.Unlock()
+				sessions[info.Session.ID()] = &tableSession{
+					created: time.Now(),
+				}
+			}
+		}
+	}
+	t.OnSessionDelete = func(info trace.TableSessionDeleteStartInfo) func(trace.TableSessionDeleteDoneInfo) {
+		if config.Details()&trace.TableSessionLifeCycleEvents == 0 {
+			return nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		s, has := sessions[info.Session.ID()]
+		if !has {
+			return nil
+		}
+		delete(sessions, info.Session.ID())
+		age.With(nil).Record(time.Since(s.created))
+		uses.With(nil).Record(float64(s.uses))
+		closes.With(map[string]string{
+			"reason": tableSessionCloseReason(s, info.Session.Status(), closing),
+		}).Inc()
+
+		return nil
+	}
+	t.OnSessionKeepAlive = func(info trace.TableKeepAliveStartInfo) func(trace.TableKeepAliveDoneInfo) {
+		id := info.Session.ID()
+
+		return func(info trace.TableKeepAliveDoneInfo) {
+			if info.Error != nil {
+				withSession(id, func(s *tableSession) {
+					s.keepAliveFailed = true
+				})
+			}
+		}
+	}
+	t.OnSessionQueryExecute = func(info trace.TableExecuteDataQueryStartInfo) func(trace.TableExecuteDataQueryDoneInfo) {
+		id := info.Session.ID()
+
+		return func(info trace.TableExecuteDataQueryDoneInfo) {
+			onSessionError(id, info.Error)
+		}
+	}
+	t.OnSessionQueryStreamExecute = func(
+		info trace.TableSessionQueryStreamExecuteStartInfo,
+	) func(trace.TableSessionQueryStreamExecuteDoneInfo) {
+		id := info.Session.ID()
+
+		return func(info trace.TableSessionQueryStreamExecuteDoneInfo) {
+			onSessionError(id, info.Error)
+		}
+	}
+	t.OnSessionQueryStreamRead = func(
+		info trace.TableSessionQueryStreamReadStartInfo,
+	) func(trace.TableSessionQueryStreamReadDoneInfo) {
+		id := info.Session.ID()
+
+		return func(info trace.TableSessionQueryStreamReadDoneInfo) {
+			onSessionError(id, info.Error)
+		}
+	}
+	t.OnSessionBulkUpsert = func(info trace.TableBulkUpsertStartInfo) func(trace.TableBulkUpsertDoneInfo) {
+		id := info.Session.ID()
+
+		return func(info trace.TableBulkUpsertDoneInfo) {
+			onSessionError(id, info.Error)
+		}
+	}
+	t.OnPoolGet = func(info trace.TablePoolGetStartInfo) func(trace.TablePoolGetDoneInfo) {
+		start := time.Now()
+
+		return func(info trace.TablePoolGetDoneInfo) {
+			if config.Details()&trace.TablePoolAPIEvents != 0 {
+				getWait.With(map[string]string{
+					"status": errorBrief(info.Error),
+				}).Record(time.Since(start))
+			}
+			if info.Error == nil && info.Session != nil {
+				withSession(info.Session.ID(), func(s *tableSession) {
+					s.uses++
+					s.idle = false
+				})
+			}
+		}
+	}
+	t.OnPoolPut = func(info trace.TablePoolPutStartInfo) func(trace.TablePoolPutDoneInfo) {
+		withSession(info.Session.ID(), func(s *tableSession) {
+			s.idle = true
+		})
+
+		return nil
+	}
+
+	return t
+}
+
+// tableSessionCloseReason returns reason of closing session, inferred from tracked session state
+func tableSessionCloseReason(s *tableSession, status string, clientClosing bool) string {
+	switch {
+	case s.badSession:
+		return "bad_session"
+	case s.keepAliveFailed:
+		return "keepalive_failure"
+	case status == "closing":
+		return "server_hint"
+	case clientClosing:
+		return "client_close"
+	case s.idle:
+		return "idle"
+	default:
+		return "other"
+	}
+}
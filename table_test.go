@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+)
+
+type testSession struct {
+	id     string
+	status string
+}
+
+func (s testSession) ID() string     { return s.id }
+func (s testSession) NodeID() uint32 { return 1 }
+func (s testSession) Status() string { return s.status }
+
+func TestTable(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	tt := table(Config(registry).WithSystem("ydb"))
+
+	newSession := func(id string) testSession {
+		s := testSession{id: id, status: "ready"}
+		tt.OnSessionNew(trace.TableSessionNewStartInfo{})(trace.TableSessionNewDoneInfo{Session: s})
+
+		return s
+	}
+	use := func(s testSession) {
+		tt.OnPoolGet(trace.TablePoolGetStartInfo{})(trace.TablePoolGetDoneInfo{Session: s})
+		tt.OnPoolPut(trace.TablePoolPutStartInfo{Session: s})
+	}
+	closeSession := func(s testSession) {
+		tt.OnSessionDelete(trace.TableSessionDeleteStartInfo{Session: s})
+	}
+
+	idle := newSession("idle")
+	use(idle)
+	use(idle)
+	closeSession(idle)
+
+	keepAlive := newSession("keepalive")
+	tt.OnSessionKeepAlive(trace.TableKeepAliveStartInfo{Session: keepAlive})(
+		trace.TableKeepAliveDoneInfo{Error: errors.New("test")},
+	)
+	closeSession(keepAlive)
+
+	hint := newSession("hint")
+	hint.status = "closing"
+	closeSession(hint)
+
+	tt.OnPoolGet(trace.TablePoolGetStartInfo{})(trace.TablePoolGetDoneInfo{Error: errors.New("test")})
+
+	assertValue(t, registry, "ydb_go_sdk_ydb_table_pool_get_wait", map[string]string{"status": "OK"}, 2)
+	assertValue(t, registry, "ydb_go_sdk_ydb_table_pool_get_wait", map[string]string{"status": "unknown"}, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_table_session_age", nil, 3)
+	assertValue(t, registry, "ydb_go_sdk_ydb_table_session_uses", nil, 3)
+	assertValue(t, registry, "ydb_go_sdk_ydb_table_session_closes", map[string]string{"reason": "idle"}, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_table_session_closes", map[string]string{"reason": "keepalive_failure"}, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_table_session_closes", map[string]string{"reason": "server_hint"}, 1)
+}
+
+func TestTableSessionCloseReason(t *testing.T) {
+	for _, tt := range []struct {
+		session  tableSession
+		status   string
+		closing  bool
+		expected string
+	}{
+		{session: tableSession{badSession: true, keepAliveFailed: true}, expected: "bad_session"},
+		{session: tableSession{keepAliveFailed: true}, status: "closing", expected: "keepalive_failure"},
+		{session: tableSession{idle: true}, status: "closing", expected: "server_hint"},
+		{session: tableSession{idle: true}, closing: true, expected: "client_close"},
+		{session: tableSession{idle: true}, expected: "idle"},
+		{session: tableSession{}, expected: "other"},
+	} {
+		if got := tableSessionCloseReason(&tt.session, tt.status, tt.closing); got != tt.expected {
+			t.Errorf("unexpected reason for %+v: %q, expected %q", tt, got, tt.expected)
+		}
+	}
+}
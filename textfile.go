@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+)
+
+// WriteTextfile atomically writes registry of adapter in exposition format to path
+// for node_exporter textfile collector. Registry must implement prometheus.Gatherer
+func (c *config) WriteTextfile(path string) error {
+	g, ok := c.registry.(prometheus.Gatherer)
+	if !ok {
+		return fmt.Errorf("registry %T is not a prometheus.Gatherer", c.registry)
+	}
+
+	return prometheus.WriteToTextfile(path, g)
+}
+
+// WithTextfile writes registry to path for node_exporter textfile collector on close of ydb driver.
+// Registry must implement prometheus.Gatherer
+func WithTextfile(path string) option {
+	return func(c *config) {
+		c.textfile = path
+	}
+}
+
+// WithTextfileOnError sets callback which called with error of writing textfile on close of ydb driver.
+// Errors are ignored if callback is not set
+func WithTextfileOnError(onError func(err error)) option {
+	return func(c *config) {
+		c.onTextfile = onError
+	}
+}
+
+// textfileTrace returns driver trace which writes registry to textfile on close of driver
+func (c *config) textfileTrace() (t trace.Driver) {
+	if c.textfile == "" {
+		return t
+	}
+	t.OnClose = func(trace.DriverCloseStartInfo) func(trace.DriverCloseDoneInfo) {
+		return func(trace.DriverCloseDoneInfo) {
+			if err := c.WriteTextfile(c.textfile); err != nil && c.onTextfile != nil {
+				c.onTextfile(err)
+			}
+		}
+	}
+
+	return t
+}
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+)
+
+func TestWriteTextfile(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	path := filepath.Join(t.TempDir(), "ydb.prom")
+	c := Config(registry, WithTextfile(path))
+	c.WithSystem("ydb").GaugeVec("conns").With(nil).Set(2)
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("file written before close of driver: %v", err)
+	}
+	c.textfileTrace().OnClose(trace.DriverCloseStartInfo{})(trace.DriverCloseDoneInfo{})
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != string(exposition(t, registry)) {
+		t.Fatalf("unexpected textfile content:\n%s", b)
+	}
+}
+
+func TestWriteTextfileNotGatherer(t *testing.T) {
+	c := Config(prometheus.WrapRegistererWithPrefix("app_", prometheus.NewRegistry()))
+	if err := c.WriteTextfile(filepath.Join(t.TempDir(), "ydb.prom")); err == nil {
+		t.Fatal("no error")
+	}
+}
+
+func TestWriteTextfileOnError(t *testing.T) {
+	var errs []error
+	c := Config(prometheus.NewRegistry(),
+		WithTextfile(filepath.Join(t.TempDir(), "not-exists", "ydb.prom")),
+		WithTextfileOnError(func(err error) {
+			errs = append(errs, err)
+		}),
+	)
+	c.textfileTrace().OnClose(trace.DriverCloseStartInfo{})(trace.DriverCloseDoneInfo{})
+
+	if len(errs) != 1 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+}
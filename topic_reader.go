@@ -0,0 +1,303 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-genproto/Ydb_Topic_V1"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb_Topic"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	ydbConfig "github.com/ydb-platform/ydb-go-sdk/v3/config"
+	"github.com/ydb-platform/ydb-go-sdk/v3/metrics"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+	"google.golang.org/grpc"
+)
+
+// topicPartition identifies partition session of topic reader
+type topicPartition struct {
+	topic     string
+	partition int64
+	session   int64
+}
+
+// topicPartitionReader is a reader connection which reads partition session
+type topicPartitionReader struct {
+	connectionID string
+	consumer     string
+}
+
+// topicCommit is a commit of offsets which waits for acknowledge from server
+type topicCommit struct {
+	endOffset int64
+	start     time.Time
+}
+
+type topicReaderMetrics struct {
+	messages        metrics.CounterVec
+	bytes           metrics.HistogramVec
+	lag             metrics.TimerVec
+	commitLatency   metrics.TimerVec
+	commitErrors    metrics.CounterVec
+	partitionStarts metrics.CounterVec
+	partitionStops  metrics.CounterVec
+	reconnects      metrics.CounterVec
+
+	partitions *boundedLabel
+}
+
+func newTopicReaderMetrics(config metrics.Config) *topicReaderMetrics {
+	config = config.WithSystem("topic").WithSystem("reader")
+
+	return &topicReaderMetrics{
+		messages:        config.CounterVec("messages", "topic", "consumer", "partition"),
+		bytes:           config.HistogramVec("message_bytes", defaultBytesBuckets, "topic", "consumer", "partition"),
+		lag:             config.TimerVec("lag", "topic", "consumer", "partition"),
+		commitLatency:   config.TimerVec("commit_latency", "topic", "consumer", "partition"),
+		commitErrors:    config.CounterVec("commit_errors", "topic", "consumer", "partition"),
+		partitionStarts: config.CounterVec("partition_starts", "topic", "consumer", "partition"),
+		partitionStops:  config.CounterVec("partition_stops", "topic", "consumer", "partition", "graceful"),
+		reconnects:      config.CounterVec("reconnects", "reason"),
+		partitions:      newBoundedLabel(config),
+	}
+}
+
+// labels returns labels of partition, partition label is guarded by limit of label values
+func (m *topicReaderMetrics) labels(topic, consumer string, partition int64) map[string]string {
+	return map[string]string{
+		"topic":    topic,
+		"consumer": consumer,
+		"partition": m.partitions.value(
+			topic+"/"+consumer+"/"+strconv.FormatInt(partition, 10), strconv.FormatInt(partition, 10),
+		),
+	}
+}
+
+// topicReader returns driver options with metrics of topic readers
+func topicReader(config metrics.Config) ydb.Option {
+	m := newTopicReaderMetrics(config)
+
+	return ydb.MergeOptions(
+		ydb.WithTraceTopic(m.trace(config)),
+		ydb.With(ydbConfig.WithGrpcOptions(grpc.WithChainStreamInterceptor(m.streamInterceptor(config)))),
+	)
+}
+
+//nolint:funlen
+func (m *topicReaderMetrics) trace(config metrics.Config) (t trace.Topic) {
+	var (
+		mu         sync.Mutex
+		consumers  = make(map[string]string)
+		partitions = make(map[topicPartition]topicPartitionReader)
+		commits    = make(map[topicPartition][]topicCommit)
+		withLabels = func(p topicPartition) map[string]string {
+			return m.labels(p.topic, partitions[p].consumer, p.partition)
+		}
+	)
+
+	t.OnReaderReconnect = func(info trace.TopicReaderReconnectStartInfo) func(trace.TopicReaderReconnectDoneInfo) {
+		if config.Details()&trace.TopicReaderStreamLifeCycleEvents != 0 {
+			m.reconnects.With(map[string]string{
+				"reason": errorBrief(info.Reason),
+			}).Inc()
+		}
+
+		return nil
+	}
+	t.OnReaderInit = func(info trace.TopicReaderInitStartInfo) func(trace.TopicReaderInitDoneInfo) {
+		var consumer string
+		if info.InitRequestInfo != nil {
+			consumer = info.InitRequestInfo.GetConsumer()
+		}
+
+		return func(info trace.TopicReaderInitDoneInfo) {
+			if info.Error == nil {
+				mu.Lock()
+				defer mu.Unlock()
+				consumers[info.ReaderConnectionID] = consumer
+			}
+		}
+	}
+	t.OnReaderClose = func(info trace.TopicReaderCloseStartInfo) func(trace.TopicReaderCloseDoneInfo) {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(consumers, info.ReaderConnectionID)
+		for p, reader := range partitions {
+			if reader.connectionID == info.ReaderConnectionID {
+				delete(partitions, p)
+				delete(commits, p)
+			}
+		}
+
+		return nil
+	}
+	t.OnReaderPartitionReadStartResponse = func(
+		info trace.TopicReaderPartitionReadStartResponseStartInfo,
+	) func(trace.TopicReaderPartitionReadStartResponseDoneInfo) {
+		p := topicPartition{topic: info.Topic, partition: info.PartitionID, session: info.PartitionSessionID}
+		connectionID := info.ReaderConnectionID
+
+		return func(info trace.TopicReaderPartitionReadStartResponseDoneInfo) {
+			if info.Error != nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			partitions[p] = topicPartitionReader{
+				connectionID: connectionID,
+				consumer:     consumers[connectionID],
+			}
+			if config.Details()&trace.TopicReaderPartitionEvents != 0 {
+				m.partitionStarts.With(withLabels(p)).Inc()
+			}
+		}
+	}
+	t.OnReaderPartitionReadStopResponse = func(
+		info trace.TopicReaderPartitionReadStopResponseStartInfo,
+	) func(trace.TopicReaderPartitionReadStopResponseDoneInfo) {
+		mu.Lock()
+		defer mu.Unlock()
+		p := topicPartition{topic: info.Topic, partition: info.PartitionID, session: info.PartitionSessionID}
+		if config.Details()&trace.TopicReaderPartitionEvents != 0 {
+			labels := withLabels(p)
+			labels["graceful"] = strconv.FormatBool(info.Graceful)
+			m.partitionStops.With(labels).Inc()
+		}
+		delete(partitions, p)
+		delete(commits, p)
+
+		return nil
+	}
+	t.OnReaderCommit = func(info trace.TopicReaderCommitStartInfo) func(trace.TopicReaderCommitDoneInfo) {
+		if config.Details()&trace.TopicReaderStreamEvents == 0 {
+			return nil
+		}
+		p := topicPartition{topic: info.Topic, partition: info.PartitionID, session: info.PartitionSessionID}
+		commit := topicCommit{endOffset: info.EndOffset, start: time.Now()}
+
+		return func(info trace.TopicReaderCommitDoneInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			if info.Error != nil {
+				m.commitErrors.With(withLabels(p)).Inc()
+
+				return
+			}
+			if _, has := partitions[p]; has {
+				commits[p] = append(commits[p], commit)
+			}
+		}
+	}
+	t.OnReaderCommittedNotify = func(info trace.TopicReaderCommittedNotifyInfo) {
+		mu.Lock()
+		defer mu.Unlock()
+		p := topicPartition{topic: info.Topic, partition: info.PartitionID, session: info.PartitionSessionID}
+		pending := commits[p]
+		for len(pending) > 0 && pending[0].endOffset <= info.CommittedOffset {
+			m.commitLatency.With(withLabels(p)).Record(time.Since(pending[0].start))
+			pending = pending[1:]
+		}
+		if len(pending) == 0 {
+			delete(commits, p)
+		} else {
+			commits[p] = pending
+		}
+	}
+
+	return t
+}
+
+func (m *topicReaderMetrics) streamInterceptor(config metrics.Config) grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil || method != Ydb_Topic_V1.TopicService_StreamRead_FullMethodName ||
+			config.Details()&trace.TopicReaderMessageEvents == 0 {
+			return stream, err
+		}
+
+		return &topicReadStream{
+			ClientStream: stream,
+			metrics:      m,
+			partitions:   make(map[int64]*Ydb_Topic.StreamReadMessage_PartitionSession),
+		}, nil
+	}
+}
+
+// topicReadStream observes messages of read stream. Consumer of stream is known from init request,
+// topic and partition of data are known from start partition session requests of same stream.
+// Reader sends and receives messages from different goroutines, so state of stream is guarded by mutex
+type topicReadStream struct {
+	grpc.ClientStream
+
+	metrics *topicReaderMetrics
+
+	m          sync.Mutex
+	consumer   string
+	partitions map[int64]*Ydb_Topic.StreamReadMessage_PartitionSession
+}
+
+func (s *topicReadStream) SendMsg(m interface{}) error {
+	if msg, ok := m.(*Ydb_Topic.StreamReadMessage_FromClient); ok {
+		s.m.Lock()
+		if init := msg.GetInitRequest(); init != nil {
+			s.consumer = init.GetConsumer()
+		}
+		if stop := msg.GetStopPartitionSessionResponse(); stop != nil {
+			delete(s.partitions, stop.GetPartitionSessionId())
+		}
+		s.m.Unlock()
+	}
+
+	return s.ClientStream.SendMsg(m)
+}
+
+func (s *topicReadStream) RecvMsg(m interface{}) error {
+	if err := s.ClientStream.RecvMsg(m); err != nil {
+		return err
+	}
+	msg, ok := m.(*Ydb_Topic.StreamReadMessage_FromServer)
+	if !ok {
+		return nil
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	if start := msg.GetStartPartitionSessionRequest(); start != nil {
+		session := start.GetPartitionSession()
+		s.partitions[session.GetPartitionSessionId()] = session
+	}
+	if stop := msg.GetStopPartitionSessionRequest(); stop != nil {
+		delete(s.partitions, stop.GetPartitionSessionId())
+	}
+	if read := msg.GetReadResponse(); read != nil {
+		s.observe(read)
+	}
+
+	return nil
+}
+
+// observe records read messages. Must be called under lock
+func (s *topicReadStream) observe(read *Ydb_Topic.StreamReadMessage_ReadResponse) {
+	now := time.Now()
+	for _, data := range read.GetPartitionData() {
+		session, has := s.partitions[data.GetPartitionSessionId()]
+		if !has {
+			continue
+		}
+		labels := s.metrics.labels(session.GetPath(), s.consumer, session.GetPartitionId())
+		messages, bytes := s.metrics.messages.With(labels), s.metrics.bytes.With(labels)
+		lag := s.metrics.lag.With(labels)
+		for _, batch := range data.GetBatches() {
+			for _, message := range batch.GetMessageData() {
+				messages.Inc()
+				bytes.Record(float64(len(message.GetData())))
+			}
+			if batch.GetWrittenAt() != nil {
+				lag.Record(now.Sub(batch.GetWrittenAt().AsTime()))
+			}
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb_Topic"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type testReadInitRequest struct {
+	consumer string
+}
+
+func (r testReadInitRequest) GetConsumer() string { return r.consumer }
+func (r testReadInitRequest) GetTopics() []string { return []string{"topic"} }
+
+func TestTopicReaderTrace(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	config := Config(registry).WithSystem("ydb")
+	tt := newTopicReaderMetrics(config).trace(config)
+
+	tt.OnReaderInit(trace.TopicReaderInitStartInfo{
+		InitRequestInfo: testReadInitRequest{consumer: "consumer"},
+	})(trace.TopicReaderInitDoneInfo{ReaderConnectionID: "conn"})
+	tt.OnReaderPartitionReadStartResponse(trace.TopicReaderPartitionReadStartResponseStartInfo{
+		ReaderConnectionID: "conn",
+		Topic:              "topic",
+		PartitionID:        3,
+		PartitionSessionID: 10,
+	})(trace.TopicReaderPartitionReadStartResponseDoneInfo{})
+
+	commit := func(endOffset int64, err error) {
+		tt.OnReaderCommit(trace.TopicReaderCommitStartInfo{
+			Topic:              "topic",
+			PartitionID:        3,
+			PartitionSessionID: 10,
+			EndOffset:          endOffset,
+		})(trace.TopicReaderCommitDoneInfo{Error: err})
+	}
+	commit(5, nil)
+	commit(7, nil)
+	commit(9, errors.New("test"))
+	tt.OnReaderCommittedNotify(trace.TopicReaderCommittedNotifyInfo{
+		ReaderConnectionID: "conn",
+		Topic:              "topic",
+		PartitionID:        3,
+		PartitionSessionID: 10,
+		CommittedOffset:    5,
+	})
+
+	tt.OnReaderPartitionReadStopResponse(trace.TopicReaderPartitionReadStopResponseStartInfo{
+		ReaderConnectionID: "conn",
+		Topic:              "topic",
+		PartitionID:        3,
+		PartitionSessionID: 10,
+		Graceful:           true,
+	})
+	// commits of stopped partition session are not tracked, even if stop is graceful
+	commit(11, nil)
+	tt.OnReaderCommittedNotify(trace.TopicReaderCommittedNotifyInfo{
+		ReaderConnectionID: "conn",
+		Topic:              "topic",
+		PartitionID:        3,
+		PartitionSessionID: 10,
+		CommittedOffset:    11,
+	})
+	tt.OnReaderReconnect(trace.TopicReaderReconnectStartInfo{Reason: errors.New("test")})
+
+	labels := map[string]string{"topic": "topic", "consumer": "consumer", "partition": "3"}
+	assertValue(t, registry, "ydb_go_sdk_ydb_topic_reader_partition_starts", labels, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_topic_reader_commit_latency", labels, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_topic_reader_commit_errors", labels, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_topic_reader_partition_stops", map[string]string{
+		"topic":     "topic",
+		"consumer":  "consumer",
+		"partition": "3",
+		"graceful":  "true",
+	}, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_topic_reader_reconnects", map[string]string{"reason": "unknown"}, 1)
+}
+
+type testTopicReadStream struct {
+	grpc.ClientStream
+
+	messages []*Ydb_Topic.StreamReadMessage_FromServer
+}
+
+func (s *testTopicReadStream) SendMsg(interface{}) error { return nil }
+
+func (s *testTopicReadStream) RecvMsg(m interface{}) error {
+	proto.Merge(m.(proto.Message), s.messages[0])
+	s.messages = s.messages[1:]
+
+	return nil
+}
+
+func TestTopicReadStream(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := newTopicReaderMetrics(Config(registry, WithLabelValuesLimit(1)).WithSystem("ydb"))
+
+	startPartition := func(session, partition int64) *Ydb_Topic.StreamReadMessage_FromServer {
+		return &Ydb_Topic.StreamReadMessage_FromServer{
+			ServerMessage: &Ydb_Topic.StreamReadMessage_FromServer_StartPartitionSessionRequest{
+				StartPartitionSessionRequest: &Ydb_Topic.StreamReadMessage_StartPartitionSessionRequest{
+					PartitionSession: &Ydb_Topic.StreamReadMessage_PartitionSession{
+						PartitionSessionId: session,
+						Path:               "topic",
+						PartitionId:        partition,
+					},
+				},
+			},
+		}
+	}
+	data := func(session int64, messages ...string) *Ydb_Topic.StreamReadMessage_ReadResponse_PartitionData {
+		batch := &Ydb_Topic.StreamReadMessage_ReadResponse_Batch{
+			WrittenAt: timestamppb.New(time.Now().Add(-time.Second)),
+		}
+		for _, message := range messages {
+			batch.MessageData = append(batch.MessageData, &Ydb_Topic.StreamReadMessage_ReadResponse_MessageData{
+				Data: []byte(message),
+			})
+		}
+
+		return &Ydb_Topic.StreamReadMessage_ReadResponse_PartitionData{
+			PartitionSessionId: session,
+			Batches:            []*Ydb_Topic.StreamReadMessage_ReadResponse_Batch{batch},
+		}
+	}
+	stream := &topicReadStream{
+		ClientStream: &testTopicReadStream{messages: []*Ydb_Topic.StreamReadMessage_FromServer{
+			startPartition(1, 0),
+			startPartition(2, 1),
+			{
+				ServerMessage: &Ydb_Topic.StreamReadMessage_FromServer_ReadResponse{
+					ReadResponse: &Ydb_Topic.StreamReadMessage_ReadResponse{
+						PartitionData: []*Ydb_Topic.StreamReadMessage_ReadResponse_PartitionData{
+							data(1, "a", "bc"),
+							data(2, "def"),
+							data(3, "unknown partition session"),
+						},
+					},
+				},
+			},
+			{
+				ServerMessage: &Ydb_Topic.StreamReadMessage_FromServer_StopPartitionSessionRequest{
+					StopPartitionSessionRequest: &Ydb_Topic.StreamReadMessage_StopPartitionSessionRequest{
+						PartitionSessionId: 1,
+						Graceful:           true,
+					},
+				},
+			},
+			{
+				ServerMessage: &Ydb_Topic.StreamReadMessage_FromServer_ReadResponse{
+					ReadResponse: &Ydb_Topic.StreamReadMessage_ReadResponse{
+						PartitionData: []*Ydb_Topic.StreamReadMessage_ReadResponse_PartitionData{
+							data(1, "stopped partition session"),
+						},
+					},
+				},
+			},
+		}},
+		metrics:    m,
+		partitions: make(map[int64]*Ydb_Topic.StreamReadMessage_PartitionSession),
+	}
+	if err := stream.SendMsg(&Ydb_Topic.StreamReadMessage_FromClient{
+		ClientMessage: &Ydb_Topic.StreamReadMessage_FromClient_InitRequest{
+			InitRequest: &Ydb_Topic.StreamReadMessage_InitRequest{Consumer: "consumer"},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := stream.RecvMsg(&Ydb_Topic.StreamReadMessage_FromServer{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first := map[string]string{"topic": "topic", "consumer": "consumer", "partition": "0"}
+	other := map[string]string{"topic": "topic", "consumer": "consumer", "partition": otherLabelValue}
+	assertValue(t, registry, "ydb_go_sdk_ydb_topic_reader_messages", first, 2)
+	assertValue(t, registry, "ydb_go_sdk_ydb_topic_reader_messages", other, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_topic_reader_message_bytes", first, 2)
+	assertValue(t, registry, "ydb_go_sdk_ydb_topic_reader_lag", first, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_topic_reader_lag", other, 1)
+}
+
+func TestTopicReadStreamConcurrent(t *testing.T) {
+	m := newTopicReaderMetrics(Config(prometheus.NewRegistry()).WithSystem("ydb"))
+
+	const sessions = 100
+	var messages []*Ydb_Topic.StreamReadMessage_FromServer
+	for session := int64(0); session < sessions; session++ {
+		messages = append(messages,
+			&Ydb_Topic.StreamReadMessage_FromServer{
+				ServerMessage: &Ydb_Topic.StreamReadMessage_FromServer_StartPartitionSessionRequest{
+					StartPartitionSessionRequest: &Ydb_Topic.StreamReadMessage_StartPartitionSessionRequest{
+						PartitionSession: &Ydb_Topic.StreamReadMessage_PartitionSession{
+							PartitionSessionId: session,
+							Path:               "topic",
+						},
+					},
+				},
+			},
+			&Ydb_Topic.StreamReadMessage_FromServer{
+				ServerMessage: &Ydb_Topic.StreamReadMessage_FromServer_ReadResponse{
+					ReadResponse: &Ydb_Topic.StreamReadMessage_ReadResponse{
+						PartitionData: []*Ydb_Topic.StreamReadMessage_ReadResponse_PartitionData{{
+							PartitionSessionId: session,
+							Batches: []*Ydb_Topic.StreamReadMessage_ReadResponse_Batch{{
+								MessageData: []*Ydb_Topic.StreamReadMessage_ReadResponse_MessageData{{}},
+							}},
+						}},
+					},
+				},
+			},
+		)
+	}
+	stream := &topicReadStream{
+		ClientStream: &testTopicReadStream{messages: messages},
+		metrics:      m,
+		partitions:   make(map[int64]*Ydb_Topic.StreamReadMessage_PartitionSession),
+	}
+
+	// reader receives messages and sends responses from different goroutines
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for session := int64(0); session < sessions; session++ {
+			_ = stream.SendMsg(&Ydb_Topic.StreamReadMessage_FromClient{
+				ClientMessage: &Ydb_Topic.StreamReadMessage_FromClient_StopPartitionSessionResponse{
+					StopPartitionSessionResponse: &Ydb_Topic.StreamReadMessage_StopPartitionSessionResponse{
+						PartitionSessionId: session,
+					},
+				},
+			})
+		}
+	}()
+	for range messages {
+		if err := stream.RecvMsg(&Ydb_Topic.StreamReadMessage_FromServer{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+}
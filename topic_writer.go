@@ -0,0 +1,283 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-genproto/Ydb_Topic_V1"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb_Topic"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	ydbConfig "github.com/ydb-platform/ydb-go-sdk/v3/config"
+	"github.com/ydb-platform/ydb-go-sdk/v3/metrics"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+	"google.golang.org/grpc"
+)
+
+// topicWrite is a call of Write which waits for acknowledge of messages from server
+type topicWrite struct {
+	start    time.Time
+	messages int
+}
+
+// topicWriterInstance is a tracked state of topic writer instance
+type topicWriterInstance struct {
+	topic     string
+	connected bool
+	writes    []topicWrite
+	queued    int
+}
+
+type topicWriterMetrics struct {
+	queue             metrics.GaugeVec
+	ackLatency        metrics.TimerVec
+	uncompressedBytes metrics.HistogramVec
+	compressedBytes   metrics.HistogramVec
+	reconnects        metrics.CounterVec
+	initErrors        metrics.CounterVec
+
+	topics *boundedLabel
+
+	m        sync.Mutex
+	writers  map[string]*topicWriterInstance
+	sessions map[string]string
+}
+
+func newTopicWriterMetrics(config metrics.Config) *topicWriterMetrics {
+	config = config.WithSystem("topic").WithSystem("writer")
+
+	return &topicWriterMetrics{
+		queue:             config.GaugeVec("queue_messages", "topic"),
+		ackLatency:        config.TimerVec("ack_latency", "topic"),
+		uncompressedBytes: config.HistogramVec("uncompressed_bytes", defaultBytesBuckets, "topic", "codec"),
+		compressedBytes:   config.HistogramVec("compressed_bytes", defaultBytesBuckets, "topic", "codec"),
+		reconnects:        config.CounterVec("reconnects", "topic"),
+		initErrors:        config.CounterVec("init_errors", "topic", "status"),
+		topics:            newBoundedLabel(config),
+		writers:           make(map[string]*topicWriterInstance),
+		sessions:          make(map[string]string),
+	}
+}
+
+// topicWriter returns driver options with metrics of topic writers
+func topicWriter(config metrics.Config) ydb.Option {
+	m := newTopicWriterMetrics(config)
+
+	return ydb.MergeOptions(
+		ydb.WithTraceTopic(m.trace(config)),
+		ydb.With(ydbConfig.WithGrpcOptions(grpc.WithChainStreamInterceptor(m.streamInterceptor(config)))),
+	)
+}
+
+func (m *topicWriterMetrics) topic(topic string) string {
+	return m.topics.value(topic, topic)
+}
+
+// writer returns tracked writer instance, creates it if writer is not tracked yet. Must be called under lock
+func (m *topicWriterMetrics) writer(instanceID string) *topicWriterInstance {
+	w, has := m.writers[instanceID]
+	if !has {
+		w = &topicWriterInstance{}
+		m.writers[instanceID] = w
+	}
+
+	return w
+}
+
+// enqueue adds messages to queue of writer. Queue gauge is updated only when topic of writer is known,
+// so messages written before first connection of writer are added on connection
+func (m *topicWriterMetrics) enqueue(w *topicWriterInstance, messages int) {
+	w.queued += messages
+	if w.topic != "" {
+		m.queue.With(map[string]string{
+			"topic": m.topic(w.topic),
+		}).Add(float64(messages))
+	}
+}
+
+//nolint:funlen
+func (m *topicWriterMetrics) trace(config metrics.Config) (t trace.Topic) {
+	t.OnWriterReconnect = func(info trace.TopicWriterReconnectStartInfo) func(trace.TopicWriterReconnectDoneInfo) {
+		if config.Details()&trace.TopicWriterStreamLifeCycleEvents == 0 {
+			return nil
+		}
+		m.m.Lock()
+		defer m.m.Unlock()
+		w := m.writer(info.WriterInstanceID)
+		if w.connected {
+			m.reconnects.With(map[string]string{
+				"topic": m.topic(info.Topic),
+			}).Inc()
+		}
+		if w.topic == "" {
+			w.topic = info.Topic
+			queued := w.queued
+			w.queued = 0
+			m.enqueue(w, queued)
+		}
+		w.connected = true
+
+		return nil
+	}
+	t.OnWriterInitStream = func(info trace.TopicWriterInitStreamStartInfo) func(trace.TopicWriterInitStreamDoneInfo) {
+		if config.Details()&trace.TopicWriterStreamLifeCycleEvents == 0 {
+			return nil
+		}
+		instanceID, topic := info.WriterInstanceID, info.Topic
+
+		return func(info trace.TopicWriterInitStreamDoneInfo) {
+			if info.Error != nil {
+				m.initErrors.With(map[string]string{
+					"topic":  m.topic(topic),
+					"status": errorBrief(info.Error),
+				}).Inc()
+
+				return
+			}
+			m.m.Lock()
+			defer m.m.Unlock()
+			m.sessions[info.SessionID] = instanceID
+		}
+	}
+	t.OnWriterCompressMessages = func(
+		info trace.TopicWriterCompressMessagesStartInfo,
+	) func(trace.TopicWriterCompressMessagesDoneInfo) {
+		if info.Reason != trace.TopicWriterCompressMessagesReasonCompressDataOnWriteReadData ||
+			config.Details()&trace.TopicWriterStreamEvents == 0 {
+			return nil
+		}
+		instanceID, messages := info.WriterInstanceID, info.MessagesCount
+		start := time.Now()
+
+		return func(info trace.TopicWriterCompressMessagesDoneInfo) {
+			if info.Error != nil {
+				return
+			}
+			m.m.Lock()
+			defer m.m.Unlock()
+			w := m.writer(instanceID)
+			w.writes = append(w.writes, topicWrite{start: start, messages: messages})
+			m.enqueue(w, messages)
+		}
+	}
+	t.OnWriterClose = func(info trace.TopicWriterCloseStartInfo) func(trace.TopicWriterCloseDoneInfo) {
+		m.m.Lock()
+		defer m.m.Unlock()
+		w, has := m.writers[info.WriterInstanceID]
+		if !has {
+			return nil
+		}
+		m.enqueue(w, -w.queued)
+		delete(m.writers, info.WriterInstanceID)
+		for sessionID, instanceID := range m.sessions {
+			if instanceID == info.WriterInstanceID {
+				delete(m.sessions, sessionID)
+			}
+		}
+
+		return nil
+	}
+
+	return t
+}
+
+// ack removes acknowledged messages from queue of writer of session and records latency from write
+func (m *topicWriterMetrics) ack(sessionID string, acks int) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	w, has := m.writers[m.sessions[sessionID]]
+	if !has {
+		return
+	}
+	if acks > w.queued {
+		acks = w.queued
+	}
+	m.enqueue(w, -acks)
+	for acks > 0 && len(w.writes) > 0 {
+		write := &w.writes[0]
+		n := min(acks, write.messages)
+		latency := m.ackLatency.With(map[string]string{
+			"topic": m.topic(w.topic),
+		})
+		for i := 0; i < n; i++ {
+			latency.Record(time.Since(write.start))
+		}
+		write.messages -= n
+		acks -= n
+		if write.messages == 0 {
+			w.writes = w.writes[1:]
+		}
+	}
+}
+
+func (m *topicWriterMetrics) streamInterceptor(config metrics.Config) grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil || method != Ydb_Topic_V1.TopicService_StreamWrite_FullMethodName ||
+			config.Details()&trace.TopicWriterStreamEvents == 0 {
+			return stream, err
+		}
+
+		return &topicWriteStream{
+			ClientStream: stream,
+			metrics:      m,
+		}, nil
+	}
+}
+
+// topicWriteStream observes messages of write stream. Topic of stream is known from init request,
+// session of stream is known from init response
+type topicWriteStream struct {
+	grpc.ClientStream
+
+	metrics   *topicWriterMetrics
+	topic     string
+	sessionID string
+}
+
+func (s *topicWriteStream) SendMsg(m interface{}) error {
+	if msg, ok := m.(*Ydb_Topic.StreamWriteMessage_FromClient); ok {
+		if init := msg.GetInitRequest(); init != nil {
+			s.topic = init.GetPath()
+		}
+		if write := msg.GetWriteRequest(); write != nil {
+			labels := map[string]string{
+				"topic": s.metrics.topic(s.topic),
+				"codec": codecName(write.GetCodec()),
+			}
+			uncompressed := s.metrics.uncompressedBytes.With(labels)
+			compressed := s.metrics.compressedBytes.With(labels)
+			for _, message := range write.GetMessages() {
+				uncompressed.Record(float64(message.GetUncompressedSize()))
+				compressed.Record(float64(len(message.GetData())))
+			}
+		}
+	}
+
+	return s.ClientStream.SendMsg(m)
+}
+
+func (s *topicWriteStream) RecvMsg(m interface{}) error {
+	if err := s.ClientStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if msg, ok := m.(*Ydb_Topic.StreamWriteMessage_FromServer); ok {
+		if init := msg.GetInitResponse(); init != nil {
+			s.sessionID = init.GetSessionId()
+		}
+		if write := msg.GetWriteResponse(); write != nil && len(write.GetAcks()) > 0 {
+			s.metrics.ack(s.sessionID, len(write.GetAcks()))
+		}
+	}
+
+	return nil
+}
+
+// codecName returns short name of topic codec (for example, "gzip" for CODEC_GZIP)
+func codecName(codec int32) string {
+	return strings.ToLower(strings.TrimPrefix(Ydb_Topic.Codec(codec).String(), "CODEC_"))
+}
@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb_Topic"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+type testTopicWriteStream struct {
+	grpc.ClientStream
+
+	messages []*Ydb_Topic.StreamWriteMessage_FromServer
+}
+
+func (s *testTopicWriteStream) SendMsg(interface{}) error { return nil }
+
+func (s *testTopicWriteStream) RecvMsg(m interface{}) error {
+	proto.Merge(m.(proto.Message), s.messages[0])
+	s.messages = s.messages[1:]
+
+	return nil
+}
+
+func TestTopicWriter(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	config := Config(registry).WithSystem("ydb")
+	m := newTopicWriterMetrics(config)
+	tt := m.trace(config)
+
+	write := func(messages int) {
+		tt.OnWriterCompressMessages(trace.TopicWriterCompressMessagesStartInfo{
+			WriterInstanceID: "writer",
+			MessagesCount:    messages,
+			Reason:           trace.TopicWriterCompressMessagesReasonCompressDataOnWriteReadData,
+		})(trace.TopicWriterCompressMessagesDoneInfo{})
+	}
+	connect := func(err error) {
+		tt.OnWriterReconnect(trace.TopicWriterReconnectStartInfo{WriterInstanceID: "writer", Topic: "topic"})
+		tt.OnWriterInitStream(trace.TopicWriterInitStreamStartInfo{WriterInstanceID: "writer", Topic: "topic"})(
+			trace.TopicWriterInitStreamDoneInfo{SessionID: "session", Error: err},
+		)
+	}
+	labels := map[string]string{"topic": "topic"}
+
+	// messages written before first connection are counted on connection
+	write(2)
+	connect(errors.New("test"))
+	assertValue(t, registry, "ydb_go_sdk_ydb_topic_writer_queue_messages", labels, 2)
+	connect(nil)
+	write(3)
+	assertValue(t, registry, "ydb_go_sdk_ydb_topic_writer_queue_messages", labels, 5)
+
+	ack := func(seqNo ...int64) *Ydb_Topic.StreamWriteMessage_FromServer {
+		response := &Ydb_Topic.StreamWriteMessage_WriteResponse{}
+		for _, n := range seqNo {
+			response.Acks = append(response.Acks, &Ydb_Topic.StreamWriteMessage_WriteResponse_WriteAck{SeqNo: n})
+		}
+
+		return &Ydb_Topic.StreamWriteMessage_FromServer{
+			ServerMessage: &Ydb_Topic.StreamWriteMessage_FromServer_WriteResponse{WriteResponse: response},
+		}
+	}
+	stream := &topicWriteStream{
+		ClientStream: &testTopicWriteStream{messages: []*Ydb_Topic.StreamWriteMessage_FromServer{
+			{
+				ServerMessage: &Ydb_Topic.StreamWriteMessage_FromServer_InitResponse{
+					InitResponse: &Ydb_Topic.StreamWriteMessage_InitResponse{SessionId: "session"},
+				},
+			},
+			ack(1, 2, 3),
+		}},
+		metrics: m,
+	}
+	if err := stream.SendMsg(&Ydb_Topic.StreamWriteMessage_FromClient{
+		ClientMessage: &Ydb_Topic.StreamWriteMessage_FromClient_InitRequest{
+			InitRequest: &Ydb_Topic.StreamWriteMessage_InitRequest{Path: "topic"},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.SendMsg(&Ydb_Topic.StreamWriteMessage_FromClient{
+		ClientMessage: &Ydb_Topic.StreamWriteMessage_FromClient_WriteRequest{
+			WriteRequest: &Ydb_Topic.StreamWriteMessage_WriteRequest{
+				Codec: int32(Ydb_Topic.Codec_CODEC_GZIP),
+				Messages: []*Ydb_Topic.StreamWriteMessage_WriteRequest_MessageData{
+					{SeqNo: 1, Data: []byte("abc"), UncompressedSize: 10},
+					{SeqNo: 2, Data: []byte("de"), UncompressedSize: 8},
+				},
+			},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := stream.RecvMsg(&Ydb_Topic.StreamWriteMessage_FromServer{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assertValue(t, registry, "ydb_go_sdk_ydb_topic_writer_queue_messages", labels, 2)
+	assertValue(t, registry, "ydb_go_sdk_ydb_topic_writer_ack_latency", labels, 3)
+
+	tt.OnWriterClose(trace.TopicWriterCloseStartInfo{WriterInstanceID: "writer"})
+	assertValue(t, registry, "ydb_go_sdk_ydb_topic_writer_queue_messages", labels, 0)
+
+	assertValue(t, registry, "ydb_go_sdk_ydb_topic_writer_reconnects", labels, 1)
+	assertValue(t, registry, "ydb_go_sdk_ydb_topic_writer_init_errors", map[string]string{
+		"topic":  "topic",
+		"status": "unknown",
+	}, 1)
+	bytesLabels := map[string]string{"topic": "topic", "codec": "gzip"}
+	assertValue(t, registry, "ydb_go_sdk_ydb_topic_writer_uncompressed_bytes", bytesLabels, 2)
+	assertValue(t, registry, "ydb_go_sdk_ydb_topic_writer_compressed_bytes", bytesLabels, 2)
+}
+
+func TestCodecName(t *testing.T) {
+	for codec, expected := range map[int32]string{
+		int32(Ydb_Topic.Codec_CODEC_RAW):  "raw",
+		int32(Ydb_Topic.Codec_CODEC_ZSTD): "zstd",
+		10001:                             "10001",
+	} {
+		if got := codecName(codec); got != expected {
+			t.Errorf("unexpected name of codec %d: %q, expected %q", codec, got, expected)
+		}
+	}
+}
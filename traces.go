@@ -8,5 +8,47 @@ import (
 )
 
 func WithTraces(registry prometheus.Registerer, opts ...option) ydb.Option {
-	return metrics.WithTraces(Config(registry, opts...))
+	return Config(registry, opts...).Traces()
+}
+
+// Traces returns ydb-go-sdk traces with all traces of this adapter for config.
+// Use Traces instead of metrics.WithTraces, which installs ydb-go-sdk traces only
+func (c *config) Traces() ydb.Option {
+	traces := []ydb.Option{
+		withTraces(c),
+		ydb.WithTraceDriver(c.textfileTrace()),
+	}
+	if c.grpcStats {
+		traces = append(traces, withGrpcStats(c.WithSystem("ydb")))
+	}
+
+	return ydb.MergeOptions(traces...)
+}
+
+// withTraces returns ydb-go-sdk metrics traces with additional traces of this adapter.
+// Some events have no traces in ydb-go-sdk (for example, commits of query service transactions,
+// attempts of retry loops or messages of topic streams). Such events are observed with driver trace
+// by called gRPC method or with gRPC interceptors by messages of streams
+func withTraces(config metrics.Config) ydb.Option {
+	ydbConfig := config.WithSystem("ydb")
+
+	return ydb.MergeOptions(
+		metrics.WithTraces(config),
+		ydb.WithTraceDriver(driver(ydbConfig)),
+		ydb.WithTraceDriver(credentials(ydbConfig)),
+		ydb.WithTraceDriver(balancer(ydbConfig)),
+		ydb.WithTraceDiscovery(discovery(ydbConfig)),
+		ydb.WithTraceTable(table(ydbConfig)),
+		ydb.WithTraceQuery(query(ydbConfig)),
+		ydb.WithTraceDriver(queryTx(ydbConfig)),
+		withQueryResultSets(ydbConfig),
+		topicReader(ydbConfig),
+		topicWriter(ydbConfig),
+		coordination(ydbConfig),
+		ratelimiter(ydbConfig),
+		scheme(ydbConfig),
+		scripting(ydbConfig),
+		ydb.WithTraceDatabaseSQL(databaseSQL(ydbConfig)),
+		withRetries(ydbConfig),
+	)
 }
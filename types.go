@@ -1,20 +1,24 @@
 package metrics
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	otelmetric "go.opentelemetry.io/otel/metric"
 
 	"github.com/ydb-platform/ydb-go-sdk/v3/metrics"
 	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
 )
 
 const (
-	defaultNamespace = "ydb_go_sdk"
-	defaultSeparator = "_"
+	defaultNamespace        = "ydb_go_sdk"
+	defaultSeparator        = "_"
+	defaultLabelValuesLimit = 100
 )
 
 var (
@@ -29,9 +33,23 @@ type config struct {
 	detailer     trace.Detailer
 	separator    string
 	registry     prometheus.Registerer
+	registerers  []prometheus.Registerer
 	namespace    string
+	path         []string
 	timerBuckets []float64
+	expvarName   string
+	textfile     string
+	onTextfile   func(err error)
+	meter        otelmetric.Meter
+	grpcStats    bool
+	labelLimit   int
+	constLabels  prometheus.Labels
+
+	// vectors shared between config and all its subsystem configs
+	vectors *vectors
+}
 
+type vectors struct {
 	m          sync.Mutex
 	counters   map[metricKey]*counterVec
 	gauges     map[metricKey]*gaugeVec
@@ -39,41 +57,137 @@ type config struct {
 	histograms map[metricKey]*histogramVec
 }
 
+func newVectors() *vectors {
+	return &vectors{
+		counters:   make(map[metricKey]*counterVec),
+		gauges:     make(map[metricKey]*gaugeVec),
+		timers:     make(map[metricKey]*timerVec),
+		histograms: make(map[metricKey]*histogramVec),
+	}
+}
+
 func Config(registry prometheus.Registerer, opts ...option) *config {
+	c := newConfig(registry, opts...)
+
+	if c.expvarName != "" {
+		c.publishExpvar()
+	}
+
+	return c
+}
+
+// newConfig is same as Config, but has no side effects of options (for example, publishing to expvar)
+func newConfig(registry prometheus.Registerer, opts ...option) *config {
 	c := &config{
 		registry:     registry,
 		detailer:     trace.DetailsAll,
 		namespace:    defaultNamespace,
 		separator:    defaultSeparator,
 		timerBuckets: defaultTimerBuckets,
+		labelLimit:   defaultLabelValuesLimit,
+		vectors:      newVectors(),
 	}
 
 	for _, o := range opts {
 		o(c)
 	}
 
+	if c.namespace != "" {
+		c.path = []string{c.namespace}
+	}
+
 	return c
 }
 
 func (c *config) CounterVec(name string, labelNames ...string) metrics.CounterVec {
 	opts := prometheus.CounterOpts{
-		Namespace: c.namespace,
-		Name:      name,
+		Namespace:   c.namespace,
+		Name:        name,
+		ConstLabels: c.constLabels,
 	}
 	counterOpts := newCounterOpts(opts)
-	c.m.Lock()
-	defer c.m.Unlock()
-	if cnt, ok := c.counters[counterOpts]; ok {
+	c.vectors.m.Lock()
+	defer c.vectors.m.Unlock()
+	if cnt, ok := c.vectors.counters[counterOpts]; ok {
 		return cnt
 	}
-	cnt := &counterVec{c: prometheus.NewCounterVec(opts, labelNames)}
-	if err := c.registry.Register(cnt.c); err != nil {
-		panic(err)
+	cnt := &counterVec{
+		c:           register(c, prometheus.NewCounterVec(opts, labelNames), nil, labelNames),
+		path:        c.joinPath(name),
+		otel:        c.otelCounter(name),
+		constLabels: c.constLabels,
 	}
-	c.counters[counterOpts] = cnt
+	c.vectors.counters[counterOpts] = cnt
 	return cnt
 }
 
+// vectorCollector is a registered vector with its specification. Specification allows to check that
+// vector with same name, which already registered by other config, has same buckets and label names
+type vectorCollector struct {
+	prometheus.Collector
+
+	buckets    string
+	labelNames string
+}
+
+// register registers collector in the main registry and in all additional
+// registerers. Collector is shared between registerers, so each observation
+// is recorded only once.
+// If vector with same name already registered in the main registry by other config (for example,
+// config of other driver with same registry) - register returns already registered vector and
+// registers it in additional registerers of this config.
+// Register panics if buckets or label names of already registered vector differ
+func register[T prometheus.Collector](c *config, collector T, buckets []float64, labelNames []string) T {
+	v := &vectorCollector{
+		Collector:  collector,
+		buckets:    fmt.Sprintf("%v", buckets),
+		labelNames: strings.Join(labelNames, ","),
+	}
+	existing, err := registerVector(c.registry, v)
+	if err != nil {
+		panic(err)
+	}
+	if existing != nil {
+		vec, ok := existing.Collector.(T)
+		if !ok {
+			panic(fmt.Errorf("collector %T already registered with type %T", collector, existing.Collector))
+		}
+		collector, v = vec, existing
+	}
+	for _, r := range c.registerers {
+		if _, err := registerVector(r, v); err != nil {
+			panic(err)
+		}
+	}
+
+	return collector
+}
+
+// registerVector registers vector in registerer. If vector with same specification already
+// registered, registerVector returns already registered vector
+func registerVector(r prometheus.Registerer, v *vectorCollector) (*vectorCollector, error) {
+	err := r.Register(v)
+	if err == nil {
+		return nil, nil
+	}
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	if !errors.As(err, &alreadyRegistered) {
+		return nil, err
+	}
+	existing, ok := alreadyRegistered.ExistingCollector.(*vectorCollector)
+	if !ok {
+		return nil, fmt.Errorf("collector with same name registered not by adapter: %w", err)
+	}
+	if existing.buckets != v.buckets || existing.labelNames != v.labelNames {
+		return nil, fmt.Errorf("vector already registered with buckets %s and labels [%s], "+
+			"requested buckets %s and labels [%s]: %w",
+			existing.buckets, existing.labelNames, v.buckets, v.labelNames, err,
+		)
+	}
+
+	return existing, nil
+}
+
 func (c *config) join(a, b string) string {
 	if a == "" {
 		return b
@@ -84,17 +198,33 @@ func (c *config) join(a, b string) string {
 	return strings.Join([]string{a, b}, c.separator)
 }
 
+// joinPath returns path (namespace split by subsystems) joined with subsystem same as join
+func (c *config) joinPath(subsystem string) []string {
+	if subsystem == "" {
+		return nil
+	}
+
+	return append(append(make([]string, 0, len(c.path)+1), c.path...), subsystem)
+}
+
 func (c *config) WithSystem(subsystem string) metrics.Config {
+	return c.withSystem(subsystem)
+}
+
+// withSystem is same as WithSystem, but returns adapter config
+func (c *config) withSystem(subsystem string) *config {
 	return &config{
 		separator:    c.separator,
 		detailer:     c.detailer,
 		registry:     c.registry,
+		registerers:  c.registerers,
 		timerBuckets: c.timerBuckets,
 		namespace:    c.join(c.namespace, subsystem),
-		counters:     make(map[metricKey]*counterVec),
-		gauges:       make(map[metricKey]*gaugeVec),
-		timers:       make(map[metricKey]*timerVec),
-		histograms:   make(map[metricKey]*histogramVec),
+		path:         c.joinPath(subsystem),
+		meter:        c.meter,
+		labelLimit:   c.labelLimit,
+		constLabels:  c.constLabels,
+		vectors:      c.vectors,
 	}
 }
 
@@ -140,7 +270,10 @@ func newTimerOpts(opts prometheus.HistogramOpts) metricKey {
 }
 
 type counterVec struct {
-	c *prometheus.CounterVec
+	c           *prometheus.CounterVec
+	path        []string
+	otel        otelmetric.Int64Counter
+	constLabels prometheus.Labels
 }
 
 func (c *counterVec) With(labels map[string]string) metrics.Counter {
@@ -148,35 +281,60 @@ func (c *counterVec) With(labels map[string]string) metrics.Counter {
 	if err != nil {
 		panic(err)
 	}
+	if c.otel != nil {
+		return &otelCounter{Counter: cnt, otel: c.otel, attrs: attributes(c.constLabels, labels)}
+	}
 	return cnt
 }
 
 type gaugeVec struct {
-	g *prometheus.GaugeVec
+	g           *prometheus.GaugeVec
+	path        []string
+	otel        otelmetric.Float64Gauge
+	constLabels prometheus.Labels
+
+	// otelMu makes change of prometheus gauge and record of its value into otel gauge atomic
+	otelMu sync.Mutex
 }
 
 type histogramVec struct {
-	h *prometheus.HistogramVec
+	h           *prometheus.HistogramVec
+	path        []string
+	otel        otelmetric.Float64Histogram
+	constLabels prometheus.Labels
 }
 
 type timerVec struct {
-	t *prometheus.HistogramVec
+	t           *prometheus.HistogramVec
+	path        []string
+	otel        otelmetric.Float64Histogram
+	constLabels prometheus.Labels
 }
 
 type timer struct {
-	o prometheus.Observer
+	o     prometheus.Observer
+	otel  otelmetric.Float64Histogram
+	attrs otelmetric.MeasurementOption
 }
 
 type histogram struct {
-	o prometheus.Observer
+	o     prometheus.Observer
+	otel  otelmetric.Float64Histogram
+	attrs otelmetric.MeasurementOption
 }
 
 func (h *timer) Record(d time.Duration) {
 	h.o.Observe(d.Seconds())
+	if h.otel != nil {
+		h.otel.Record(context.Background(), d.Seconds(), h.attrs)
+	}
 }
 
 func (h *histogram) Record(v float64) {
 	h.o.Observe(v)
+	if h.otel != nil {
+		h.otel.Record(context.Background(), v, h.attrs)
+	}
 }
 
 func (h *timerVec) With(labels map[string]string) metrics.Timer {
@@ -184,6 +342,9 @@ func (h *timerVec) With(labels map[string]string) metrics.Timer {
 	if err != nil {
 		panic(err)
 	}
+	if h.otel != nil {
+		return &timer{o: observer, otel: h.otel, attrs: attributes(h.constLabels, labels)}
+	}
 	return &timer{o: observer}
 }
 
@@ -192,6 +353,9 @@ func (h *histogramVec) With(labels map[string]string) metrics.Histogram {
 	if err != nil {
 		panic(err)
 	}
+	if h.otel != nil {
+		return &histogram{o: observer, otel: h.otel, attrs: attributes(h.constLabels, labels)}
+	}
 	return &histogram{o: observer}
 }
 
@@ -200,65 +364,77 @@ func (g *gaugeVec) With(labels map[string]string) metrics.Gauge {
 	if err != nil {
 		panic(err)
 	}
+	if g.otel != nil {
+		return &otelGauge{Gauge: gauge, otel: g.otel, attrs: attributes(g.constLabels, labels), m: &g.otelMu}
+	}
 	return gauge
 }
 
 func (c *config) GaugeVec(name string, labelNames ...string) metrics.GaugeVec {
 	opts := prometheus.GaugeOpts{
-		Namespace: c.namespace,
-		Name:      name,
+		Namespace:   c.namespace,
+		Name:        name,
+		ConstLabels: c.constLabels,
 	}
 	gaugeOpts := newGaugeOpts(opts)
-	c.m.Lock()
-	defer c.m.Unlock()
-	if g, ok := c.gauges[gaugeOpts]; ok {
+	c.vectors.m.Lock()
+	defer c.vectors.m.Unlock()
+	if g, ok := c.vectors.gauges[gaugeOpts]; ok {
 		return g
 	}
-	g := &gaugeVec{g: prometheus.NewGaugeVec(opts, labelNames)}
-	if err := c.registry.Register(g.g); err != nil {
-		panic(err)
+	g := &gaugeVec{
+		g:           register(c, prometheus.NewGaugeVec(opts, labelNames), nil, labelNames),
+		path:        c.joinPath(name),
+		otel:        c.otelGauge(name),
+		constLabels: c.constLabels,
 	}
-	c.gauges[gaugeOpts] = g
+	c.vectors.gauges[gaugeOpts] = g
 	return g
 }
 
 func (c *config) TimerVec(name string, labelNames ...string) metrics.TimerVec {
 	opts := prometheus.HistogramOpts{
-		Namespace: c.namespace,
-		Name:      name,
-		Buckets:   c.timerBuckets,
+		Namespace:   c.namespace,
+		Name:        name,
+		Buckets:     c.timerBuckets,
+		ConstLabels: c.constLabels,
 	}
 	timersOpts := newTimerOpts(opts)
-	c.m.Lock()
-	defer c.m.Unlock()
-	if t, ok := c.timers[timersOpts]; ok {
+	c.vectors.m.Lock()
+	defer c.vectors.m.Unlock()
+	if t, ok := c.vectors.timers[timersOpts]; ok {
 		return t
 	}
-	t := &timerVec{t: prometheus.NewHistogramVec(opts, labelNames)}
-	if err := c.registry.Register(t.t); err != nil {
-		panic(err)
+	t := &timerVec{
+		t:           register(c, prometheus.NewHistogramVec(opts, labelNames), opts.Buckets, labelNames),
+		path:        c.joinPath(name),
+		otel:        c.otelHistogram(name, "s", c.timerBuckets),
+		constLabels: c.constLabels,
 	}
-	c.timers[timersOpts] = t
+	c.vectors.timers[timersOpts] = t
 	return t
 }
 
 func (c *config) HistogramVec(name string, buckets []float64, labelNames ...string) metrics.HistogramVec {
 	opts := prometheus.HistogramOpts{
-		Namespace: c.namespace,
-		Name:      name,
-		Buckets:   buckets,
+		Namespace:   c.namespace,
+		Name:        name,
+		Buckets:     buckets,
+		ConstLabels: c.constLabels,
 	}
 	histogramsOpts := newHistogramOpts(opts)
-	c.m.Lock()
-	defer c.m.Unlock()
-	if h, ok := c.histograms[histogramsOpts]; ok {
+	c.vectors.m.Lock()
+	defer c.vectors.m.Unlock()
+	if h, ok := c.vectors.histograms[histogramsOpts]; ok {
 		return h
 	}
-	h := &histogramVec{h: prometheus.NewHistogramVec(opts, labelNames)}
-	if err := c.registry.Register(h.h); err != nil {
-		panic(err)
+	h := &histogramVec{
+		h:           register(c, prometheus.NewHistogramVec(opts, labelNames), opts.Buckets, labelNames),
+		path:        c.joinPath(name),
+		otel:        c.otelHistogram(name, "", buckets),
+		constLabels: c.constLabels,
 	}
-	c.histograms[histogramsOpts] = h
+	c.vectors.histograms[histogramsOpts] = h
 	return h
 }
 
@@ -295,6 +471,22 @@ func WithSeparator(separator string) option {
 	}
 }
 
+// WithAdditionalRegisterer registers every metric vector also in given registerers
+// (for example, a registry which pushed to aggregator in addition to scraped registry)
+func WithAdditionalRegisterer(registerers ...prometheus.Registerer) option {
+	return func(c *config) {
+		c.registerers = append(c.registerers, registerers...)
+	}
+}
+
+// WithConstLabels adds labels with constant values to every metric of adapter
+// (for example, name of service or database)
+func WithConstLabels(labels prometheus.Labels) option {
+	return func(c *config) {
+		c.constLabels = labels
+	}
+}
+
 func WithTimerBuckets(timerBuckets []float64) option {
 	return func(c *config) {
 		c.timerBuckets = timerBuckets
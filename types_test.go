@@ -0,0 +1,221 @@
+package metrics
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/expfmt"
+	ydbMetrics "github.com/ydb-platform/ydb-go-sdk/v3/metrics"
+	"github.com/ydb-platform/ydb-go-sdk/v3/trace"
+
+	"github.com/ydb-platform/ydb-go-sdk-prometheus/v2/metricstest"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestConformance(t *testing.T) {
+	metricstest.RunGathered(t, func(t *testing.T) (ydbMetrics.Config, prometheus.Gatherer) {
+		registry := prometheus.NewRegistry()
+
+		return Config(registry), registry
+	})
+}
+
+func TestConformanceWithOptions(t *testing.T) {
+	metricstest.RunGathered(t, func(t *testing.T) (ydbMetrics.Config, prometheus.Gatherer) {
+		registry := prometheus.NewRegistry()
+
+		return Config(registry,
+			WithNamespace("app"),
+			WithSeparator("__"),
+			WithDetailer(trace.DriverConnEvents|trace.RetryEvents),
+			WithTimerBuckets([]float64{0.01, 0.1, 1}),
+			WithAdditionalRegisterer(prometheus.NewRegistry()),
+		), registry
+	})
+}
+
+func TestExposition(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts []option
+	}{
+		{
+			name: "default",
+		},
+		{
+			name: "options",
+			opts: []option{
+				WithNamespace("app"),
+				WithSeparator("__"),
+				WithTimerBuckets([]float64{0.5, 1}),
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := prometheus.NewRegistry()
+			c := Config(registry, tt.opts...).WithSystem("ydb")
+			retry := c.WithSystem("retry")
+			retry.CounterVec("errors", "status").With(map[string]string{"status": "OK"}).Inc()
+			retry.HistogramVec("attempts", []float64{1, 2}, "retry_label").
+				With(map[string]string{"retry_label": "label"}).Record(2)
+			retry.TimerVec("latency").With(nil).Record(750 * time.Millisecond)
+			c.WithSystem("driver").GaugeVec("conns", "endpoint").
+				With(map[string]string{"endpoint": "localhost:2136"}).Set(3)
+			// same subsystem and name requested again
+			c.WithSystem("retry").CounterVec("errors", "status").With(map[string]string{"status": "OK"}).Inc()
+
+			golden(t, registry, filepath.Join("testdata", tt.name+".golden"))
+		})
+	}
+}
+
+func TestAdditionalRegisterer(t *testing.T) {
+	main, additional := prometheus.NewRegistry(), prometheus.NewRegistry()
+	c := Config(main, WithAdditionalRegisterer(additional)).WithSystem("ydb")
+	c.CounterVec("counter").With(nil).Inc()
+	c.WithSystem("sub").GaugeVec("gauge").With(nil).Set(5)
+
+	expected := exposition(t, main)
+	if len(expected) == 0 {
+		t.Fatal("main registry is empty")
+	}
+	if err := testutil.GatherAndCompare(additional, bytes.NewReader(expected)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSameRegistry(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	Config(registry).WithSystem("ydb").CounterVec("counter", "a").With(map[string]string{"a": "1"}).Inc()
+	Config(registry).WithSystem("ydb").CounterVec("counter", "a").With(map[string]string{"a": "1"}).Inc()
+	assertValue(t, registry, "ydb_go_sdk_ydb_counter", map[string]string{"a": "1"}, 2)
+}
+
+func TestSameRegistryAdditionalRegisterers(t *testing.T) {
+	registry, first, second := prometheus.NewRegistry(), prometheus.NewRegistry(), prometheus.NewRegistry()
+	Config(registry, WithAdditionalRegisterer(first)).WithSystem("ydb").
+		CounterVec("counter", "a").With(map[string]string{"a": "1"}).Inc()
+	Config(registry, WithAdditionalRegisterer(first), WithAdditionalRegisterer(second)).WithSystem("ydb").
+		CounterVec("counter", "a").With(map[string]string{"a": "1"}).Inc()
+	for _, g := range []prometheus.Gatherer{registry, first, second} {
+		assertValue(t, g, "ydb_go_sdk_ydb_counter", map[string]string{"a": "1"}, 2)
+	}
+}
+
+func TestInvalidNames(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		f    func(c ydbMetrics.Config)
+	}{
+		{
+			name: "label name",
+			f: func(c ydbMetrics.Config) {
+				c.CounterVec("counter", "bad-label")
+			},
+		},
+		{
+			name: "metric name",
+			f: func(c ydbMetrics.Config) {
+				c.GaugeVec("bad.gauge")
+			},
+		},
+		{
+			name: "separator",
+			f: func(c ydbMetrics.Config) {
+				Config(prometheus.NewRegistry(), WithSeparator(".")).
+					WithSystem("ydb").TimerVec("timer")
+			},
+		},
+		{
+			name: "unknown label",
+			f: func(c ydbMetrics.Config) {
+				c.CounterVec("counter", "a").With(map[string]string{"b": "1"})
+			},
+		},
+		{
+			name: "missing label",
+			f: func(c ydbMetrics.Config) {
+				c.HistogramVec("histogram", []float64{1}, "a", "b").With(map[string]string{"a": "1"})
+			},
+		},
+		{
+			name: "other type with same name",
+			f: func(c ydbMetrics.Config) {
+				c.CounterVec("metric")
+				c.GaugeVec("metric")
+			},
+		},
+		{
+			name: "other buckets with same name",
+			f: func(c ydbMetrics.Config) {
+				c.HistogramVec("histogram", []float64{1, 2}, "a")
+				c.HistogramVec("histogram", []float64{1, 2, 3}, "a")
+			},
+		},
+		{
+			name: "other buckets with same name in other config",
+			f: func(c ydbMetrics.Config) {
+				registry := prometheus.NewRegistry()
+				Config(registry).WithSystem("ydb").HistogramVec("histogram", []float64{1, 2}, "a")
+				Config(registry).WithSystem("ydb").HistogramVec("histogram", []float64{1, 2, 3}, "a")
+			},
+		},
+		{
+			name: "other labels with same name in other config",
+			f: func(c ydbMetrics.Config) {
+				registry := prometheus.NewRegistry()
+				Config(registry).WithSystem("ydb").CounterVec("counter", "a")
+				Config(registry).WithSystem("ydb").CounterVec("counter", "b")
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("no panic")
+				}
+			}()
+			tt.f(Config(prometheus.NewRegistry()).WithSystem("ydb"))
+		})
+	}
+}
+
+func golden(t *testing.T, g prometheus.Gatherer, path string) {
+	t.Helper()
+	if *update {
+		if err := os.WriteFile(path, exposition(t, g), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer expected.Close()
+	if err = testutil.GatherAndCompare(g, expected); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func exposition(t *testing.T, g prometheus.Gatherer) []byte {
+	t.Helper()
+	families, err := g.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	for _, f := range families {
+		if _, err = expfmt.MetricFamilyToText(&b, f); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return b.Bytes()
+}
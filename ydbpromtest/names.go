@@ -0,0 +1,59 @@
+// Package ydbpromtest contains helpers for asserting ydb-go-sdk metrics in tests
+package ydbpromtest
+
+import (
+	"strings"
+)
+
+const (
+	defaultNamespace = "ydb_go_sdk"
+	defaultSeparator = "_"
+)
+
+// Namer builds full metric names by the same rules as prometheus adapter for ydb-go-sdk:
+// subsystems are joined to namespace with separator, and name is joined to namespace with "_"
+type Namer struct {
+	Namespace string
+	Separator string
+}
+
+// DefaultNamer builds names for adapter with default namespace and separator
+var DefaultNamer = Namer{
+	Namespace: defaultNamespace,
+	Separator: defaultSeparator,
+}
+
+// Name returns full metric name. Last part is a metric name, all previous parts are subsystems
+//
+// Example: Name("ydb", "retry", "errors") returns "ydb_go_sdk_ydb_retry_errors"
+func (n Namer) Name(parts ...string) string {
+	if len(parts) == 0 {
+		return ""
+	}
+	namespace := n.Namespace
+	for _, subsystem := range parts[:len(parts)-1] {
+		namespace = n.join(namespace, subsystem)
+	}
+	name := parts[len(parts)-1]
+	if namespace == "" {
+		return name
+	}
+
+	return namespace + "_" + name
+}
+
+func (n Namer) join(a, b string) string {
+	if a == "" {
+		return b
+	}
+	if b == "" {
+		return ""
+	}
+
+	return strings.Join([]string{a, b}, n.Separator)
+}
+
+// Name returns full metric name using DefaultNamer
+func Name(parts ...string) string {
+	return DefaultNamer.Name(parts...)
+}
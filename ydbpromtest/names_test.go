@@ -0,0 +1,31 @@
+package ydbpromtest
+
+import (
+	"testing"
+)
+
+func TestName(t *testing.T) {
+	for _, tt := range []struct {
+		namer    Namer
+		parts    []string
+		expected string
+	}{
+		{namer: DefaultNamer, parts: []string{"ydb", "retry", "errors"}, expected: "ydb_go_sdk_ydb_retry_errors"},
+		{namer: DefaultNamer, parts: []string{"errors"}, expected: "ydb_go_sdk_errors"},
+		{namer: DefaultNamer, parts: nil, expected: ""},
+		{
+			namer:    Namer{Namespace: "app", Separator: "."},
+			parts:    []string{"ydb", "retry", "errors"},
+			expected: "app.ydb.retry_errors",
+		},
+		{namer: Namer{Separator: "_"}, parts: []string{"ydb", "errors"}, expected: "ydb_errors"},
+		{namer: Namer{Separator: "_"}, parts: []string{"errors"}, expected: "errors"},
+	} {
+		if name := tt.namer.Name(tt.parts...); name != tt.expected {
+			t.Errorf("unexpected name of %v: %q, expected %q", tt.parts, name, tt.expected)
+		}
+	}
+	if name := Name("ydb", "retry", "errors"); name != "ydb_go_sdk_ydb_retry_errors" {
+		t.Errorf("unexpected name: %q", name)
+	}
+}
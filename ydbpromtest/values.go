@@ -0,0 +1,213 @@
+package ydbpromtest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// CounterValue returns sum of counters with given name which have all given labels.
+// Value is zero if no series of metric have given labels, so absence of series can be asserted.
+// Error is returned if metric not found or metric has other type
+func CounterValue(g prometheus.Gatherer, name string, labels map[string]string) (float64, error) {
+	var value float64
+	err := walk(g, name, labels, func(m *dto.Metric) error {
+		if m.GetCounter() == nil {
+			return fmt.Errorf("metric %q is not a counter", name)
+		}
+		value += m.GetCounter().GetValue()
+
+		return nil
+	})
+
+	return value, err
+}
+
+// GaugeValue returns sum of gauges with given name which have all given labels
+func GaugeValue(g prometheus.Gatherer, name string, labels map[string]string) (float64, error) {
+	var value float64
+	err := walk(g, name, labels, func(m *dto.Metric) error {
+		if m.GetGauge() == nil {
+			return fmt.Errorf("metric %q is not a gauge", name)
+		}
+		value += m.GetGauge().GetValue()
+
+		return nil
+	})
+
+	return value, err
+}
+
+// HistogramCount returns sum of observations count of histograms (and timers)
+// with given name which have all given labels
+func HistogramCount(g prometheus.Gatherer, name string, labels map[string]string) (uint64, error) {
+	var count uint64
+	err := walk(g, name, labels, func(m *dto.Metric) error {
+		if m.GetHistogram() == nil {
+			return fmt.Errorf("metric %q is not a histogram", name)
+		}
+		count += m.GetHistogram().GetSampleCount()
+
+		return nil
+	})
+
+	return count, err
+}
+
+// HistogramSum returns sum of observed values of histograms (and timers)
+// with given name which have all given labels
+func HistogramSum(g prometheus.Gatherer, name string, labels map[string]string) (float64, error) {
+	var sum float64
+	err := walk(g, name, labels, func(m *dto.Metric) error {
+		if m.GetHistogram() == nil {
+			return fmt.Errorf("metric %q is not a histogram", name)
+		}
+		sum += m.GetHistogram().GetSampleSum()
+
+		return nil
+	})
+
+	return sum, err
+}
+
+// AssertSeriesExist reports error to t for each name which has no series in g
+func AssertSeriesExist(t testing.TB, g prometheus.Gatherer, names ...string) {
+	t.Helper()
+	families, err := g.Gather()
+	if err != nil {
+		t.Errorf("gather failed: %v", err)
+
+		return
+	}
+	exists := make(map[string]bool, len(families))
+	for _, f := range families {
+		exists[f.GetName()] = len(f.GetMetric()) > 0
+	}
+	for _, name := range names {
+		if !exists[name] {
+			t.Errorf("series %q not exists", name)
+		}
+	}
+}
+
+// Snapshot is a flat view of gathered series: key is series in exposition
+// format (name{label="value",...}), value is counter, gauge or untyped value.
+// Histograms are presented by _count and _sum series
+type Snapshot map[string]float64
+
+// Take gathers all series from g
+func Take(g prometheus.Gatherer) (Snapshot, error) {
+	families, err := g.Gather()
+	if err != nil {
+		return nil, err
+	}
+	s := make(Snapshot)
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			labels := seriesLabels(m)
+			switch {
+			case m.GetCounter() != nil:
+				s[f.GetName()+labels] = m.GetCounter().GetValue()
+			case m.GetGauge() != nil:
+				s[f.GetName()+labels] = m.GetGauge().GetValue()
+			case m.GetUntyped() != nil:
+				s[f.GetName()+labels] = m.GetUntyped().GetValue()
+			case m.GetHistogram() != nil:
+				s[f.GetName()+"_count"+labels] = float64(m.GetHistogram().GetSampleCount())
+				s[f.GetName()+"_sum"+labels] = m.GetHistogram().GetSampleSum()
+			case m.GetSummary() != nil:
+				s[f.GetName()+"_count"+labels] = float64(m.GetSummary().GetSampleCount())
+				s[f.GetName()+"_sum"+labels] = m.GetSummary().GetSampleSum()
+			}
+		}
+	}
+
+	return s, nil
+}
+
+// Diff returns series which changed between before and after snapshots with its deltas.
+// Series which appeared in after snapshot are compared with zero
+func Diff(before, after Snapshot) Snapshot {
+	diff := make(Snapshot)
+	for k, v := range after {
+		if delta := v - before[k]; delta != 0 {
+			diff[k] = delta
+		}
+	}
+	for k, v := range before {
+		if _, has := after[k]; !has && v != 0 {
+			diff[k] = -v
+		}
+	}
+
+	return diff
+}
+
+// String returns snapshot as sorted lines
+func (s Snapshot) String() string {
+	keys := make([]string, 0, len(s))
+	for k := range s {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s %v\n", k, s[k])
+	}
+
+	return b.String()
+}
+
+func walk(g prometheus.Gatherer, name string, labels map[string]string, f func(m *dto.Metric) error) error {
+	families, err := g.Gather()
+	if err != nil {
+		return err
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			if !hasLabels(m, labels) {
+				continue
+			}
+			if err := f(m); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("metric %q not found", name)
+}
+
+func hasLabels(m *dto.Metric, labels map[string]string) bool {
+	matched := 0
+	for _, l := range m.GetLabel() {
+		if v, has := labels[l.GetName()]; has {
+			if v != l.GetValue() {
+				return false
+			}
+			matched++
+		}
+	}
+
+	return matched == len(labels)
+}
+
+func seriesLabels(m *dto.Metric) string {
+	if len(m.GetLabel()) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(m.GetLabel()))
+	for _, l := range m.GetLabel() {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", l.GetName(), l.GetValue()))
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
@@ -0,0 +1,151 @@
+package ydbpromtest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func testRegistry(t *testing.T) *prometheus.Registry {
+	t.Helper()
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "errors"}, []string{"status", "method"})
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "conns"})
+	histogram := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "latency"}, []string{"method"})
+	registry.MustRegister(counter, gauge, histogram)
+
+	counter.WithLabelValues("OK", "get").Add(2)
+	counter.WithLabelValues("OK", "put").Add(3)
+	counter.WithLabelValues("ABORTED", "put").Inc()
+	gauge.Set(4)
+	histogram.WithLabelValues("get").Observe(0.5)
+	histogram.WithLabelValues("get").Observe(1.5)
+
+	return registry
+}
+
+func TestValues(t *testing.T) {
+	registry := testRegistry(t)
+
+	for _, tt := range []struct {
+		labels   map[string]string
+		expected float64
+	}{
+		{labels: map[string]string{"status": "OK", "method": "get"}, expected: 2},
+		{labels: map[string]string{"status": "OK"}, expected: 5},
+		{labels: nil, expected: 6},
+		{labels: map[string]string{"status": "UNAVAILABLE"}, expected: 0},
+		{labels: map[string]string{"unknown": "label"}, expected: 0},
+	} {
+		value, err := CounterValue(registry, "errors", tt.labels)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != tt.expected {
+			t.Errorf("unexpected value of errors%v: %v, expected %v", tt.labels, value, tt.expected)
+		}
+	}
+	if value, err := GaugeValue(registry, "conns", nil); err != nil || value != 4 {
+		t.Errorf("unexpected value of conns: %v (%v)", value, err)
+	}
+	if count, err := HistogramCount(registry, "latency", map[string]string{"method": "get"}); err != nil || count != 2 {
+		t.Errorf("unexpected count of latency: %v (%v)", count, err)
+	}
+	if sum, err := HistogramSum(registry, "latency", map[string]string{"method": "get"}); err != nil || sum != 2 {
+		t.Errorf("unexpected sum of latency: %v (%v)", sum, err)
+	}
+}
+
+func TestValuesErrors(t *testing.T) {
+	registry := testRegistry(t)
+
+	for name, f := range map[string]func() error{
+		"missing family": func() error {
+			_, err := CounterValue(registry, "unknown", nil)
+
+			return err
+		},
+		"counter is gauge": func() error {
+			_, err := CounterValue(registry, "conns", nil)
+
+			return err
+		},
+		"gauge is counter": func() error {
+			_, err := GaugeValue(registry, "errors", nil)
+
+			return err
+		},
+		"histogram count of counter": func() error {
+			_, err := HistogramCount(registry, "errors", nil)
+
+			return err
+		},
+		"histogram sum of gauge": func() error {
+			_, err := HistogramSum(registry, "conns", nil)
+
+			return err
+		},
+	} {
+		if err := f(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+// recordingTB records errors reported by assertions
+type recordingTB struct {
+	testing.TB
+
+	errors []string
+}
+
+func (tb *recordingTB) Helper() {}
+
+func (tb *recordingTB) Errorf(format string, args ...interface{}) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertSeriesExist(t *testing.T) {
+	registry := testRegistry(t)
+	registry.MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{Name: "empty"}, []string{"status"}))
+
+	tb := &recordingTB{TB: t}
+	AssertSeriesExist(tb, registry, "errors", "conns", "latency", "empty", "unknown")
+	if len(tb.errors) != 2 ||
+		!strings.Contains(tb.errors[0], `"empty"`) || !strings.Contains(tb.errors[1], `"unknown"`) {
+		t.Errorf("unexpected errors: %v", tb.errors)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "errors"}, []string{"status"})
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "latency"})
+	registry.MustRegister(counter, histogram)
+	counter.WithLabelValues("OK").Inc()
+
+	before, err := Take(registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter.WithLabelValues("OK").Add(2)
+	counter.WithLabelValues("ABORTED").Inc()
+	histogram.Observe(0.25)
+	after, err := Take(registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "errors{status=\"ABORTED\"} 1\n" +
+		"errors{status=\"OK\"} 2\n" +
+		"latency_count 1\n" +
+		"latency_sum 0.25\n"
+	if diff := Diff(before, after).String(); diff != expected {
+		t.Errorf("unexpected diff:\n%s\nexpected:\n%s", diff, expected)
+	}
+	if diff := Diff(after, before); diff["errors{status=\"ABORTED\"}"] != -1 {
+		t.Errorf("unexpected diff of disappeared series: %v", diff)
+	}
+}